@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bufio"
+	"chirpy/internal/auth"
+	"chirpy/internal/config"
+	"chirpy/internal/database"
+	"chirpy/internal/outbox"
+	"chirpy/internal/store"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const adminUsage = `usage: chirpy admin [-db-url URL] [-output table|json] COMMAND [ARGS]
+
+Commands:
+  create-user EMAIL [PASSWORD]   create a user
+  reset-password USER [PASSWORD] set a new password for a user
+  grant-red USER                 upgrade a user to Chirpy Red
+  revoke-red USER                remove Chirpy Red from a user
+  set-role USER ROLE             make a user a user, moderator or admin
+  revoke-sessions USER           revoke every refresh token of a user
+  delete-chirp CHIRP_ID          delete a chirp
+  stats                          print row counts
+
+USER is an email address or a user ID. When PASSWORD is omitted it is
+read from the first line of stdin. The database URL defaults to $DB_URL.`
+
+// adminCmd carries what every admin subcommand needs.
+type adminCmd struct {
+	db     *sql.DB
+	q      *database.Queries
+	out    io.Writer
+	in     io.Reader
+	asJSON bool
+}
+
+// runAdmin implements the "chirpy admin" subcommand.
+func runAdmin(args []string) error {
+	fs := flag.NewFlagSet("admin", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), adminUsage) }
+	dbURL := fs.String("db-url", os.Getenv("DB_URL"), "database connection URL")
+	output := fs.String("output", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return errors.New("admin: expected a command")
+	}
+	if *output != "table" && *output != "json" {
+		return fmt.Errorf("admin: -output must be table or json, got %q", *output)
+	}
+	if *dbURL == "" {
+		return errors.New("admin: DB_URL or -db-url is required")
+	}
+
+	driver := config.DBConfig{URL: *dbURL}.Driver()
+	if driver == config.DriverMemory {
+		return errors.New("admin: the in-memory store only lives inside a running server")
+	}
+	db, err := store.Open(driver, *dbURL)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer db.Close()
+
+	a := &adminCmd{
+		db:     db,
+		q:      database.New(db),
+		out:    os.Stdout,
+		in:     os.Stdin,
+		asJSON: *output == "json",
+	}
+
+	ctx := context.Background()
+	cmd, rest := fs.Arg(0), fs.Args()[1:]
+	switch cmd {
+	case "create-user":
+		return a.createUser(ctx, rest)
+	case "reset-password":
+		return a.resetPassword(ctx, rest)
+	case "grant-red":
+		return a.setChirpyRed(ctx, rest, true)
+	case "revoke-red":
+		return a.setChirpyRed(ctx, rest, false)
+	case "set-role":
+		return a.setRole(ctx, rest)
+	case "revoke-sessions":
+		return a.revokeSessions(ctx, rest)
+	case "delete-chirp":
+		return a.deleteChirp(ctx, rest)
+	case "stats":
+		return a.stats(ctx, rest)
+	default:
+		fs.Usage()
+		return fmt.Errorf("admin: unknown command %q", cmd)
+	}
+}
+
+func (a *adminCmd) createUser(ctx context.Context, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("usage: chirpy admin create-user EMAIL [PASSWORD]")
+	}
+	password, err := a.password(args[1:])
+	if err != nil {
+		return err
+	}
+	hash, err := auth.HashPassword(ctx, password)
+	if err != nil {
+		return fmt.Errorf("hashing password: %w", err)
+	}
+	now := time.Now().UTC()
+	user, err := a.q.CreateUser(ctx, database.CreateUserParams{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Email:          args[0],
+		HashedPassword: hash,
+	})
+	if err != nil {
+		return fmt.Errorf("creating user: %w", err)
+	}
+	return a.printUser(user)
+}
+
+func (a *adminCmd) resetPassword(ctx context.Context, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("usage: chirpy admin reset-password USER [PASSWORD]")
+	}
+	user, err := a.findUser(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	password, err := a.password(args[1:])
+	if err != nil {
+		return err
+	}
+	hash, err := auth.HashPassword(ctx, password)
+	if err != nil {
+		return fmt.Errorf("hashing password: %w", err)
+	}
+
+	// Existing sessions were opened with the old password; end them too.
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := a.q.WithTx(tx)
+
+	now := time.Now().UTC()
+	err = qtx.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+		ID:             user.ID,
+		HashedPassword: hash,
+		UpdatedAt:      now,
+	})
+	if err != nil {
+		return fmt.Errorf("updating password: %w", err)
+	}
+	revoked, err := qtx.RevokeAllRefreshTokensForUser(ctx, revokeAllParams(user.ID, now))
+	if err != nil {
+		return fmt.Errorf("revoking sessions: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing: %w", err)
+	}
+	return a.printResult(map[string]any{
+		"user_id":          user.ID,
+		"email":            user.Email,
+		"password_reset":   true,
+		"sessions_revoked": revoked,
+	})
+}
+
+func (a *adminCmd) setChirpyRed(ctx context.Context, args []string, red bool) error {
+	if len(args) != 1 {
+		return errors.New("usage: chirpy admin grant-red|revoke-red USER")
+	}
+	user, err := a.findUser(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if red {
+		_, err = a.q.UpgradeToChirpyRed(ctx, user.ID)
+	} else {
+		err = a.q.RevokeChirpyRed(ctx, user.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("updating Chirpy Red: %w", err)
+	}
+	user.IsChirpyRed = red
+	return a.printUser(user)
+}
+
+// setRole is how the first admin is appointed; after that admins can
+// also use PUT /admin/users/{userID}/role.
+func (a *adminCmd) setRole(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: chirpy admin set-role USER user|moderator|admin")
+	}
+	role, err := auth.ParseRole(args[1])
+	if err != nil {
+		return err
+	}
+	user, err := a.findUser(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	user, err = a.q.UpdateUserRole(ctx, database.UpdateUserRoleParams{
+		ID:        user.ID,
+		Role:      string(role),
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("updating role: %w", err)
+	}
+	return a.printUser(user)
+}
+
+func (a *adminCmd) revokeSessions(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: chirpy admin revoke-sessions USER")
+	}
+	user, err := a.findUser(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	revoked, err := a.q.RevokeAllRefreshTokensForUser(ctx, revokeAllParams(user.ID, time.Now().UTC()))
+	if err != nil {
+		return fmt.Errorf("revoking sessions: %w", err)
+	}
+	return a.printResult(map[string]any{
+		"user_id":          user.ID,
+		"email":            user.Email,
+		"sessions_revoked": revoked,
+	})
+}
+
+func (a *adminCmd) deleteChirp(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: chirpy admin delete-chirp CHIRP_ID")
+	}
+	chirpID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid chirp ID %q: %w", args[0], err)
+	}
+
+	// Same as the API: the delete and its outbox event commit together.
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := a.q.WithTx(tx)
+
+	chirp, err := qtx.GetChirpByID(ctx, chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("chirp %s not found", chirpID)
+	}
+	if err != nil {
+		return fmt.Errorf("looking up chirp: %w", err)
+	}
+	if err := qtx.DeleteChirp(ctx, chirp.ID); err != nil {
+		return fmt.Errorf("deleting chirp: %w", err)
+	}
+	err = outbox.Enqueue(ctx, qtx, outbox.EventChirpDeleted, chirp.ID, outbox.ChirpPayload{
+		ChirpID:   chirp.ID,
+		UserID:    chirp.UserID,
+		CreatedAt: chirp.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing: %w", err)
+	}
+	return a.printResult(map[string]any{
+		"chirp_id": chirp.ID,
+		"user_id":  chirp.UserID,
+		"deleted":  true,
+	})
+}
+
+func (a *adminCmd) stats(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: chirpy admin stats")
+	}
+	s, err := a.q.GetStats(ctx, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("reading stats: %w", err)
+	}
+	return a.printResult(map[string]any{
+		"users":            s.Users,
+		"chirpy_red_users": s.ChirpyRedUsers,
+		"chirps":           s.Chirps,
+		"active_sessions":  s.ActiveSessions,
+		"pending_events":   s.PendingEvents,
+	})
+}
+
+func revokeAllParams(userID uuid.UUID, now time.Time) database.RevokeAllRefreshTokensForUserParams {
+	return database.RevokeAllRefreshTokensForUserParams{
+		UserID:    userID,
+		RevokedAt: sql.NullTime{Time: now, Valid: true},
+		UpdatedAt: now,
+	}
+}
+
+// findUser resolves USER arguments, which may be a UUID or an email.
+func (a *adminCmd) findUser(ctx context.Context, ref string) (database.User, error) {
+	var (
+		user database.User
+		err  error
+	)
+	if id, parseErr := uuid.Parse(ref); parseErr == nil {
+		user, err = a.q.GetUserByID(ctx, id)
+	} else {
+		user, err = a.q.GetUserByEmail(ctx, ref)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, fmt.Errorf("user %q not found", ref)
+	}
+	if err != nil {
+		return user, fmt.Errorf("looking up user: %w", err)
+	}
+	return user, nil
+}
+
+// password returns the password argument, or the first stdin line so that
+// it does not have to appear in shell history.
+func (a *adminCmd) password(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	line, err := bufio.NewReader(a.in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("reading password: %w", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("password is required")
+	}
+	return password, nil
+}
+
+func (a *adminCmd) printUser(user database.User) error {
+	return a.printResult(map[string]any{
+		"id":            user.ID,
+		"email":         user.Email,
+		"created_at":    user.CreatedAt.UTC().Format(time.RFC3339),
+		"is_chirpy_red": user.IsChirpyRed,
+		"role":          user.Role,
+	})
+}
+
+// printResult writes a single record as JSON or as an aligned key/value
+// table with sorted keys.
+func (a *adminCmd) printResult(fields map[string]any) error {
+	if a.asJSON {
+		enc := json.NewEncoder(a.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(fields)
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	tw := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	for _, k := range keys {
+		fmt.Fprintf(tw, "%s\t%v\n", strings.ToUpper(k), fields[k])
+	}
+	return tw.Flush()
+}
@@ -2,96 +2,356 @@ package main
 
 import (
 	"chirpy/internal/api"
-	"chirpy/internal/database"
-	"chirpy/internal/handlers"
+	"chirpy/internal/config"
+	"chirpy/internal/health"
 	"chirpy/internal/logger"
+	"chirpy/internal/metrics"
 	"chirpy/internal/middleware"
+	"chirpy/internal/migrate"
+	"chirpy/internal/moderation"
+	"chirpy/internal/outbox"
+	"chirpy/internal/profanity"
+	"chirpy/internal/pubsub"
+	"chirpy/internal/ratelimit"
+	"chirpy/internal/server"
+	"chirpy/internal/store"
+	"chirpy/internal/stream"
+	"chirpy/internal/tracing"
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
 )
 
 func main() {
-	// Load .env
-	if err := godotenv.Load(); err != nil {
+	// .env is optional: containers inject real environment variables.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
 		logger.Logger.Fatalw("Error loading .env file", "error", err)
 	}
 
-	dbURL := os.Getenv("DB_URL")
-	if dbURL == "" {
-		logger.Logger.Fatal("DB_URL not set in environment")
+	var err error
+	args := os.Args[1:]
+	switch {
+	case len(args) > 0 && args[0] == "admin":
+		err = runAdmin(args[1:])
+	case len(args) > 0 && args[0] == "migrate":
+		err = runMigrate(args[1:])
+	case len(args) > 0 && args[0] == "serve":
+		err = runServer(args[1:])
+	default:
+		err = runServer(args)
 	}
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		logger.Logger.Fatal("JWT_SECRET is required in .env")
+	if err != nil {
+		logger.Logger.Errorw("Exited with error", "error", err)
+		logger.Sync()
+		os.Exit(1)
 	}
+	logger.Sync()
+}
 
-	platform := os.Getenv("PLATFORM")
-
-	polkaKey := os.Getenv("POLKA_KEY")
-	if polkaKey == "" {
-		logger.Logger.Fatal("POLKA_KEY is required")
+// runServer owns every resource so that deferred cleanup executes on both
+// normal shutdown and startup failure.
+func runServer(args []string) error {
+	conf, err := config.Load(args, os.Getenv)
+	if err != nil {
+		return err
 	}
+	logger.Logger.Infof("Effective configuration:\n%s", conf)
+
+	// Cancelled on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	// Open DB
-	db, err := sql.Open("postgres", dbURL)
+	// Tracing
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		ServiceName: "chirpy",
+		Exporter:    conf.Tracing.Exporter,
+		FilePath:    conf.Tracing.File,
+	})
 	if err != nil {
-		logger.Logger.Fatalw("Failed to open database", "error", err)
+		return fmt.Errorf("setting up tracing: %w", err)
 	}
-	defer db.Close()
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Logger.Warnw("Failed to flush traces", "error", err)
+		}
+	}()
 
-	if err = db.Ping(); err != nil {
-		logger.Logger.Fatalw("Database ping failed", "error", err)
+	// Storage
+	driver := conf.DB.Driver()
+	var (
+		db *sql.DB
+		st store.Store
+	)
+	switch driver {
+	case config.DriverMemory:
+		logger.Logger.Warnw("Using the in-memory store; data is lost when the server stops")
+		st = store.NewMemory()
+	default:
+		db, err = openDB(ctx, conf)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		st = store.NewSQL(db, driver)
+		metrics.RegisterDBStats(db)
 	}
 
-	dbQueries := database.New(db)
+	wordlist, err := newWordlist(ctx, st, conf.Chirps.Profanity)
+	if err != nil {
+		return err
+	}
+
+	checker := health.NewChecker()
+
+	hub := stream.NewHub()
+	hub.ReplaySize = conf.Stream.ReplaySize
+	hub.ClientBuffer = conf.Stream.ClientBuffer
+	hub.Heartbeat = conf.Stream.Heartbeat
 
 	// Initialize config
 	cfg := &api.Config{
-		DB:       dbQueries,
-		Platform: platform,
-		JWTSecret: jwtSecret,
-		PolkaKey: polkaKey,
+		DB:                 st,
+		Health:             checker,
+		Platform:           conf.Platform,
+		JWTSecret:          conf.Auth.JWTSecret,
+		PolkaKey:           conf.Polka.APIKey,
+		HealthToken:        conf.Server.HealthToken,
+		MetricsToken:       conf.Server.MetricsToken,
+		QueryTimeout:       conf.DB.QueryTimeout,
+		RouteQueryTimeouts: conf.DB.RouteQueryTimeouts,
+		AccessTokenTTL:     conf.Auth.AccessTokenTTL,
+		RefreshTokenTTL:    conf.Auth.RefreshTokenTTL,
+		ChirpMaxLength:     conf.Chirps.MaxLength,
+		Moderation:         wordlist,
+		Stream:             hub,
+		AllowedOrigins:     conf.Server.CORS.AllowedOrigins,
+	}
+
+	// Background workers run until shutdown cancels workerCtx.
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+
+	// Share live events and wordlist edits with the other instances
+	var ps pubsub.PubSub = pubsub.NewMemory()
+	if conf.PubSub.Backend == config.PubSubPostgres {
+		pg := pubsub.NewPostgres(db, conf.DB.URL)
+		defer pg.Close()
+		ps = pg
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			pg.Run(workerCtx)
+		}()
+	}
+	if err := hub.Attach(ps); err != nil {
+		return fmt.Errorf("attaching chirp stream: %w", err)
 	}
+	if err := wordlist.Attach(ps); err != nil {
+		return fmt.Errorf("attaching moderation wordlist: %w", err)
+	}
+
+	// Readiness checks
+	checker.Register("server", health.NotShuttingDown(cfg.ShuttingDown.Load))
 
-	// Setup router
-	mux := http.NewServeMux()
+	// The in-memory store has no database, migrations or relay to watch.
+	if db != nil {
+		// Outbox relay delivers committed domain events to in-process
+		// subscribers. Nothing consumes them yet (webhooks, search
+		// indexing and the like are future work), so events are only
+		// logged and marked published.
+		relay := outbox.NewRelay(db, driver)
+		relay.SkipLocked = driver == config.DriverPostgres
+		relay.Subscribe(outbox.AllEvents, func(ctx context.Context, evt outbox.Event) error {
+			logger.Logger.Debugw("Outbox event published",
+				"event_id", evt.ID,
+				"event_type", evt.Type,
+				"aggregate_id", evt.AggregateID,
+			)
+			return nil
+		})
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			relay.Run(workerCtx)
+		}()
 
-	// Health
-	mux.HandleFunc("GET /api/healthz", func(w http.ResponseWriter, r *http.Request) {
-		logger.Logger.Infow("Health check", "path", r.URL.Path, "method", r.Method)
-		w.Header().Set("Content-Type", "text/plain")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
+		schemaCheck, err := migrate.NewChecker(db, driver)
+		if err != nil {
+			return fmt.Errorf("preparing migration check: %w", err)
+		}
+		checker.Register("database", health.DBPing(db))
+		checker.Register("migrations", schemaCheck.Check)
+		checker.Register("outbox_relay", relay.Check)
+
+		// Pick up wordlist edits made through other instances
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			wordlist.Run(workerCtx)
+		}()
+	}
+
+	serverOpts := []server.Option{
+		server.WithSecurityHeaders(middleware.SecurityOptions{
+			HSTSMaxAge:            conf.Server.HSTSMaxAge,
+			ContentSecurityPolicy: conf.Server.ContentSecurityPolicy,
+		}),
+	}
+	if len(conf.Server.CORS.AllowedOrigins) > 0 {
+		serverOpts = append(serverOpts, server.WithCORS(middleware.CORSOptions{
+			AllowedOrigins:   conf.Server.CORS.AllowedOrigins,
+			AllowCredentials: conf.Server.CORS.AllowCredentials,
+			MaxAge:           conf.Server.CORS.MaxAge,
+		}))
+	}
+	if !conf.Server.Compression {
+		serverOpts = append(serverOpts, server.WithoutCompression())
+	}
+	if conf.RateLimit.Enabled {
+		limiter := newRateLimiter(conf, db)
+		serverOpts = append(serverOpts, server.WithRateLimiter(limiter))
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			limiter.Run(workerCtx)
+		}()
+	}
+
+	srv := &http.Server{
+		Addr:              conf.Addr(),
+		Handler:           server.New(cfg, serverOpts...),
+		ReadHeaderTimeout: conf.Server.ReadHeaderTimeout,
+		ReadTimeout:       conf.Server.ReadTimeout,
+		WriteTimeout:      conf.Server.WriteTimeout,
+		IdleTimeout:       conf.Server.IdleTimeout,
+	}
+	// Open streams would otherwise hold Shutdown until its timeout.
+	srv.RegisterOnShutdown(hub.Close)
+
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", srv.Addr, err)
+	}
+	logger.Logger.Infow("Server starting", "addr", srv.Addr, "platform", conf.Platform)
+	drainDelay := conf.Server.DrainDelay
+	if conf.Platform == "dev" {
+		drainDelay = 0
+	}
+	// A second signal while draining kills the process.
+	context.AfterFunc(ctx, stop)
+	err = server.Serve(ctx, srv, ln, server.ShutdownOptions{
+		ShuttingDown: &cfg.ShuttingDown,
+		DrainDelay:   drainDelay,
+		Timeout:      conf.Server.ShutdownTimeout,
 	})
 
-	// Static files with logging middleware
-	fileServer := http.FileServer(http.Dir("."))
-	mux.Handle("/app/", middleware.Logging(middleware.MetricsInc(cfg, http.StripPrefix("/app", fileServer))))
-
-	// Admin
-	mux.HandleFunc("GET /admin/metrics", handlers.HandleMetrics(cfg))
-	mux.HandleFunc("POST /admin/reset", handlers.HandleReset(cfg))
-
-	// API
-	mux.HandleFunc("POST /api/login", handlers.HandleLogin(cfg))
-	mux.HandleFunc("POST /api/refresh", handlers.HandleTokenRefresh(cfg))
-	mux.HandleFunc("POST /api/revoke", handlers.HandleTokenRevoke(cfg))
-	mux.HandleFunc("POST /api/users", handlers.HandleCreateUser(cfg))
-	mux.HandleFunc("PUT /api/users", handlers.HandleUpdateUser(cfg))
-	mux.HandleFunc("POST /api/chirps", handlers.HandleCreateChirp(cfg))
-	mux.HandleFunc("GET /api/chirps", handlers.HandleGetAllChirps(cfg))
-	mux.HandleFunc("GET /api/chirps/{chirpID}", handlers.HandleGetChirpByID(cfg))
-	mux.HandleFunc("DELETE /api/chirps/{chirpID}", handlers.HandleDeleteChirp(cfg))
-
-	// Webhook
-	mux.HandleFunc("POST /api/polka/webhooks", handlers.HandlePolkaWebhook(cfg))
-
-	// Start server
-	logger.Logger.Infow("Server starting", "port", 8080, "platform", platform)
-	logger.Logger.Fatal(http.ListenAndServe(":8080", mux))
-}
\ No newline at end of file
+	// Stop the workers whether the server was signalled or failed, and
+	// keep a failed drain as the exit status.
+	stopWorkers()
+	workers.Wait()
+	if err != nil {
+		logger.Logger.Errorw("Server stopped with errors", "error", err)
+		return err
+	}
+	logger.Logger.Infow("Server stopped")
+	return nil
+}
+
+// openDB connects to the configured Postgres or SQLite database and
+// makes sure its schema is current, applying migrations first when asked
+// to.
+func openDB(ctx context.Context, conf *config.Config) (*sql.DB, error) {
+	driver := conf.DB.Driver()
+	db, err := store.Open(driver, conf.DB.URL)
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	// SQLite pool limits are set by store.Open.
+	if driver == config.DriverPostgres {
+		db.SetMaxOpenConns(conf.DB.MaxOpenConns)
+		db.SetMaxIdleConns(conf.DB.MaxIdleConns)
+		db.SetConnMaxLifetime(conf.DB.ConnMaxLifetime)
+		db.SetConnMaxIdleTime(conf.DB.ConnMaxIdleTime)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database ping failed: %w", err)
+	}
+
+	if conf.DB.MigrateOnStart {
+		results, err := migrate.Up(ctx, db, driver)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("applying migrations: %w", err)
+		}
+		for _, r := range results {
+			logger.Logger.Infow("Applied migration", "migration", r.Source.Path, "duration", r.Duration)
+		}
+	}
+
+	// Refuse to serve against a schema older than this binary expects.
+	if err := migrate.CheckCurrent(ctx, db, driver); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w (run \"chirpy migrate up\" or start with -migrate-on-start)", err)
+	}
+	return db, nil
+}
+
+// newRateLimiter builds the limiter for conf.RateLimit. The database
+// backend uses db, which Validate guarantees exists.
+func newRateLimiter(conf *config.Config, db *sql.DB) *ratelimit.Limiter {
+	var st ratelimit.Store = ratelimit.NewMemory()
+	if conf.RateLimit.Backend == config.RateLimitDatabase {
+		sqlStore := ratelimit.NewSQL(db, conf.DB.Driver())
+		sqlStore.ForUpdate = conf.DB.Driver() == config.DriverPostgres
+		st = sqlStore
+	}
+
+	rules := make(map[string]ratelimit.Limit, len(conf.RateLimit.Routes))
+	for route, rule := range conf.RateLimit.Routes {
+		rules[route] = ratelimit.Limit{Requests: rule.Requests, Per: rule.Per, Burst: rule.Burst}
+	}
+	return ratelimit.New(st, rules, ratelimit.UserOrIP(conf.Auth.JWTSecret, conf.RateLimit.TrustForwardedFor))
+}
+
+// newWordlist compiles the chirp filter from the configured wordlist, or
+// the built-in one, plus the words stored through the admin API.
+func newWordlist(ctx context.Context, st store.Store, conf config.ProfanityConfig) (*moderation.Wordlist, error) {
+	words := profanity.DefaultWords
+	if conf.WordsFile != "" {
+		var err error
+		if words, err = profanity.LoadFile(conf.WordsFile); err != nil {
+			return nil, err
+		}
+	}
+	wordlist, err := moderation.NewWordlist(st, words, profanity.Options{
+		Strategy:    profanity.Strategy(conf.Strategy),
+		Leetspeak:   conf.Leetspeak,
+		FoldRepeats: conf.FoldRepeats,
+	})
+	if err != nil {
+		return nil, err
+	}
+	wordlist.ReloadInterval = conf.ReloadInterval
+	if _, err := wordlist.Reload(ctx); err != nil {
+		return nil, fmt.Errorf("loading moderation wordlist: %w", err)
+	}
+	return wordlist, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"chirpy/internal/config"
+	"chirpy/internal/migrate"
+	"chirpy/internal/store"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pressly/goose/v3"
+)
+
+const migrateUsage = `usage: chirpy migrate [-db-url URL] up|down|status|redo
+
+Applies the migrations embedded in this binary. The database URL
+defaults to $DB_URL.`
+
+// runMigrate implements the "chirpy migrate" subcommand.
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), migrateUsage) }
+	dbURL := fs.String("db-url", os.Getenv("DB_URL"), "database connection URL")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("migrate: expected exactly one command")
+	}
+	if *dbURL == "" {
+		return errors.New("migrate: DB_URL or -db-url is required")
+	}
+
+	driver := config.DBConfig{URL: *dbURL}.Driver()
+	if driver == config.DriverMemory {
+		return errors.New("migrate: the in-memory store has no schema")
+	}
+	db, err := store.Open(driver, *dbURL)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch cmd := fs.Arg(0); cmd {
+	case "up":
+		results, err := migrate.Up(ctx, db, driver)
+		printResults(results)
+		if err == nil && len(results) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+	case "down":
+		result, err := migrate.Down(ctx, db, driver)
+		if result != nil {
+			printResults([]*goose.MigrationResult{result})
+		}
+		return err
+	case "redo":
+		results, err := migrate.Redo(ctx, db, driver)
+		printResults(results)
+		return err
+	case "status":
+		statuses, err := migrate.Status(ctx, db, driver)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "APPLIED AT\tMIGRATION")
+		for _, st := range statuses {
+			applied := "pending"
+			if st.State == goose.StateApplied {
+				applied = st.AppliedAt.Local().Format(time.DateTime)
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", applied, st.Source.Path)
+		}
+		return tw.Flush()
+	default:
+		fs.Usage()
+		return fmt.Errorf("migrate: unknown command %q", cmd)
+	}
+}
+
+func printResults(results []*goose.MigrationResult) {
+	for _, r := range results {
+		fmt.Printf("%-4s %-5s %s (%s)\n", "OK", r.Direction, r.Source.Path, r.Duration.Round(time.Millisecond))
+	}
+}
@@ -1,13 +1,13 @@
 -- name: CreateChirps :one
 INSERT INTO chirps (id, created_at, updated_at, body, user_id) 
 VALUES (
-    gen_random_uuid(),
-    NOW(),
-    NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5
 )
-RETURNING id, created_at, updated_at, body, user_id;
+RETURNING id, created_at, updated_at, body, user_id, hidden_at;
 
 -- name: DeleteAllChirps :exec
 DELETE FROM chirps;
@@ -23,6 +23,12 @@ SELECT * FROM chirps;
 SELECT * FROM chirps WHERE id = $1;
 
 -- name: GetChirpsByAuthor :many
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, hidden_at
 FROM chirps
-WHERE user_id = $1;
\ No newline at end of file
+WHERE user_id = $1;
+
+-- name: HideChirp :execrows
+UPDATE chirps
+SET hidden_at = $2,
+    updated_at = $3
+WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,26 @@
+-- name: CreateModerationWord :one
+INSERT INTO moderation_words (id, created_at, updated_at, word, severity)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: DeleteModerationWord :execrows
+DELETE FROM moderation_words
+WHERE id = $1;
+
+-- name: ListModerationWords :many
+SELECT * FROM moderation_words
+ORDER BY word;
+
+-- name: UpdateModerationWord :one
+UPDATE moderation_words
+SET word = $2,
+    severity = $3,
+    updated_at = $4
+WHERE id = $1
+RETURNING *;
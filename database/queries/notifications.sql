@@ -0,0 +1,76 @@
+-- name: AddNotificationActor :execrows
+INSERT INTO notification_actors (notification_id, actor_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT DO NOTHING;
+
+-- name: BumpNotification :one
+UPDATE notifications
+SET actor_count = actor_count + 1,
+    last_actor_id = $2,
+    updated_at = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: CreateNotification :one
+-- Returns no row when the group already has an unread notification, which
+-- a concurrent action may have inserted since GetUnreadNotificationGroup.
+INSERT INTO notifications (id, created_at, updated_at, user_id, type, chirp_id, last_actor_id, actor_count)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    1
+)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetUnreadNotificationGroup :one
+-- The unread notification that a new one of the same type on the same
+-- chirp (or on none, for follows) joins.
+SELECT * FROM notifications
+WHERE user_id = $1
+  AND type = $2
+  AND chirp_id IS NOT DISTINCT FROM $3
+  AND read_at IS NULL
+ORDER BY updated_at DESC
+LIMIT 1;
+
+-- name: ListNotifications :many
+-- Newest first, starting after the (updated_at, id) cursor.
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+  AND (updated_at < sqlc.arg(before_at)
+       OR (updated_at = sqlc.arg(tie_at) AND id < sqlc.arg(before_id)))
+ORDER BY updated_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListUnreadNotifications :many
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+  AND read_at IS NULL
+  AND (updated_at < sqlc.arg(before_at)
+       OR (updated_at = sqlc.arg(tie_at) AND id < sqlc.arg(before_id)))
+ORDER BY updated_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = $2
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications
+SET read_at = $3
+WHERE id = $1 AND user_id = $2 AND read_at IS NULL;
@@ -0,0 +1,36 @@
+-- name: InsertOutboxEvent :exec
+INSERT INTO outbox_events (id, created_at, event_type, aggregate_id, payload)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: ClaimPendingOutboxEvents :many
+SELECT * FROM outbox_events
+WHERE published_at IS NULL
+  AND attempts < $1
+ORDER BY created_at
+LIMIT $2
+FOR UPDATE SKIP LOCKED;
+
+-- name: ListPendingOutboxEvents :many
+-- Same as ClaimPendingOutboxEvents for SQLite, which has no row locks.
+SELECT * FROM outbox_events
+WHERE published_at IS NULL
+  AND attempts < $1
+ORDER BY created_at
+LIMIT $2;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events
+SET published_at = $2
+WHERE id = $1;
+
+-- name: RecordOutboxEventFailure :exec
+UPDATE outbox_events
+SET attempts = attempts + 1,
+    last_error = $2
+WHERE id = $1;
@@ -2,7 +2,7 @@
 INSERT INTO refresh_tokens (
     token, user_id, expires_at, created_at, updated_at
 ) VALUES (
-    $1, $2, $3, NOW(), NOW()
+    $1, $2, $3, $4, $5
 );
 
 -- name: GetRefreshToken :one
@@ -11,5 +11,11 @@ WHERE token = $1;
 
 -- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens
-SET revoked_at = $1, updated_at = NOW()
-WHERE token = $2;
\ No newline at end of file
+SET revoked_at = $1, updated_at = $2
+WHERE token = $3;
+
+-- name: RevokeAllRefreshTokensForUser :execrows
+UPDATE refresh_tokens
+SET revoked_at = $2, updated_at = $3
+WHERE user_id = $1
+  AND revoked_at IS NULL;
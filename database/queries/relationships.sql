@@ -0,0 +1,48 @@
+-- name: CreateBlock :one
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+RETURNING *;
+
+-- name: DeleteBlock :execrows
+DELETE FROM blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: GetBlock :one
+SELECT * FROM blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: ListBlocks :many
+SELECT * FROM blocks
+WHERE blocker_id = $1
+ORDER BY created_at, blocked_id;
+
+-- name: CreateMute :one
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+RETURNING *;
+
+-- name: DeleteMute :execrows
+DELETE FROM mutes
+WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: ListMutes :many
+SELECT * FROM mutes
+WHERE muter_id = $1
+ORDER BY created_at, muted_id;
+
+-- name: ListHiddenAuthors :many
+-- Authors whose chirps a viewer should not see: users they blocked or
+-- muted, and users who blocked them. Kind is 'block' or 'mute'.
+SELECT blocked_id AS user_id, 'block' AS kind FROM blocks WHERE blocker_id = $1
+UNION ALL
+SELECT blocker_id AS user_id, 'block' AS kind FROM blocks WHERE blocked_id = $2
+UNION ALL
+SELECT muted_id AS user_id, 'mute' AS kind FROM mutes WHERE muter_id = $3;
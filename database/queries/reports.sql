@@ -0,0 +1,49 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, updated_at, chirp_id, reporter_id, reason, status)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    'open'
+)
+RETURNING *;
+
+-- name: GetReport :one
+SELECT * FROM reports WHERE id = $1;
+
+-- name: ListReports :many
+-- Reports with the chirp they are about, oldest first.
+SELECT reports.id, reports.created_at, reports.updated_at, reports.chirp_id,
+       reports.reporter_id, reports.reason, reports.status, reports.action,
+       reports.resolved_at,
+       chirps.body AS chirp_body,
+       chirps.user_id AS chirp_user_id,
+       chirps.created_at AS chirp_created_at,
+       chirps.updated_at AS chirp_updated_at,
+       chirps.hidden_at AS chirp_hidden_at
+FROM reports
+JOIN chirps ON chirps.id = reports.chirp_id
+WHERE reports.status = $1
+ORDER BY reports.created_at, reports.id;
+
+-- name: ResolveReport :one
+UPDATE reports
+SET status = 'resolved',
+    action = $2,
+    resolved_at = $3,
+    updated_at = $4
+WHERE id = $1
+  AND status = 'open'
+RETURNING *;
+
+-- name: ResolveOpenReportsForChirp :execrows
+UPDATE reports
+SET status = 'resolved',
+    action = $2,
+    resolved_at = $3,
+    updated_at = $4
+WHERE chirp_id = $1
+  AND status = 'open';
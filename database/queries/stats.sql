@@ -0,0 +1,8 @@
+-- name: GetStats :one
+SELECT
+    (SELECT COUNT(*) FROM users) AS users,
+    (SELECT COUNT(*) FROM users WHERE is_chirpy_red) AS chirpy_red_users,
+    (SELECT COUNT(*) FROM chirps) AS chirps,
+    (SELECT COUNT(*) FROM refresh_tokens
+        WHERE revoked_at IS NULL AND expires_at > sqlc.arg(now)) AS active_sessions,
+    (SELECT COUNT(*) FROM outbox_events WHERE published_at IS NULL) AS pending_events;
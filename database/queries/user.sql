@@ -1,22 +1,22 @@
 -- name: CreateUser :one
 INSERT INTO users (id, created_at, updated_at, email, hashed_password)
 VALUES (
-    gen_random_uuid(),
-    NOW(),
-    NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red;
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, suspended_until, role;
 
 -- name: UpdateUser :one
 UPDATE users
 SET 
     email = $2,
     hashed_password = $3,
-    updated_at = NOW()
+    updated_at = $4
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red;
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, suspended_until, role;
 
 -- name: GetUserByEmail :one
 SELECT * FROM users WHERE email = $1;
@@ -24,7 +24,34 @@ SELECT * FROM users WHERE email = $1;
 -- name: DeleteAllUsers :exec
 DELETE FROM users;
 
--- name: UpgradeToChirpyRed :exec
+-- name: UpgradeToChirpyRed :execrows
 UPDATE users
 SET is_chirpy_red = TRUE
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = $1;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET hashed_password = $2,
+    updated_at = $3
+WHERE id = $1;
+
+-- name: RevokeChirpyRed :exec
+UPDATE users
+SET is_chirpy_red = FALSE
+WHERE id = $1;
+
+-- name: SuspendUser :execrows
+UPDATE users
+SET suspended_until = $2,
+    updated_at = $3
+WHERE id = $1;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2,
+    updated_at = $3
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, suspended_until, role;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE moderation_words (
+    id UUID PRIMARY KEY,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    word TEXT not null UNIQUE,
+    severity TEXT not null CHECK (severity IN ('mask', 'block'))
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE moderation_words;
+-- +goose StatementEnd
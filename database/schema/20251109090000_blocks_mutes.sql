@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE blocks (
+    blocker_id UUID not null,
+    blocked_id UUID not null,
+    created_at timestamp not null,
+
+    PRIMARY KEY (blocker_id, blocked_id),
+    CONSTRAINT fk_blocks_blocker
+        FOREIGN KEY (blocker_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_blocks_blocked
+        FOREIGN KEY (blocked_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT chk_blocks_not_self
+        CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX idx_blocks_blocked_id
+    ON blocks (blocked_id);
+
+CREATE TABLE mutes (
+    muter_id UUID not null,
+    muted_id UUID not null,
+    created_at timestamp not null,
+
+    PRIMARY KEY (muter_id, muted_id),
+    CONSTRAINT fk_mutes_muter
+        FOREIGN KEY (muter_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_mutes_muted
+        FOREIGN KEY (muted_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT chk_mutes_not_self
+        CHECK (muter_id <> muted_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE mutes;
+
+DROP TABLE blocks;
+-- +goose StatementEnd
@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    user_id UUID not null,
+    type TEXT not null
+        CHECK (type IN ('mention', 'reply', 'like', 'follow')),
+    chirp_id UUID,
+    last_actor_id UUID not null,
+    actor_count INTEGER not null DEFAULT 1,
+    read_at timestamp,
+
+    CONSTRAINT fk_notifications_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_notifications_chirp
+        FOREIGN KEY (chirp_id)
+        REFERENCES chirps(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_notifications_last_actor
+        FOREIGN KEY (last_actor_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_notifications_user_id_updated_at
+    ON notifications (user_id, updated_at);
+
+CREATE TABLE notification_actors (
+    notification_id UUID not null,
+    actor_id UUID not null,
+    created_at timestamp not null,
+
+    PRIMARY KEY (notification_id, actor_id),
+    CONSTRAINT fk_notification_actors_notification
+        FOREIGN KEY (notification_id)
+        REFERENCES notifications(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_notification_actors_actor
+        FOREIGN KEY (actor_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE notification_actors;
+
+DROP TABLE notifications;
+-- +goose StatementEnd
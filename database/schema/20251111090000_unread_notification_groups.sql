@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- At most one unread notification per group, so concurrent actions join
+-- the same group instead of each starting one. Follows have no chirp and
+-- NULLs never conflict, so they get their own index.
+CREATE UNIQUE INDEX idx_notifications_unread_group
+    ON notifications (user_id, type, chirp_id)
+    WHERE read_at IS NULL;
+
+CREATE UNIQUE INDEX idx_notifications_unread_group_no_chirp
+    ON notifications (user_id, type)
+    WHERE read_at IS NULL AND chirp_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_notifications_unread_group_no_chirp;
+
+DROP INDEX idx_notifications_unread_group;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+// Package schema embeds the goose migrations so the binary can apply
+// them without an external tool.
+package schema
+
+import "embed"
+
+// FS holds the Postgres migrations.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// SQLiteFS holds the SQLite migrations under "sqlite/". They carry the
+// same versions as the Postgres ones so both schemas move in lockstep.
+//
+//go:embed sqlite/*.sql
+var SQLiteFS embed.FS
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE users (
+    id TEXT primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    email text not null unique
+)
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE users;
+-- +goose StatementEnd
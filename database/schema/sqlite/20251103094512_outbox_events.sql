@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE outbox_events (
+    id TEXT PRIMARY KEY,
+    created_at timestamp not null,
+    event_type TEXT not null,
+    aggregate_id TEXT not null,
+    payload BLOB not null,
+    attempts INTEGER not null default 0,
+    last_error TEXT,
+    published_at timestamp
+);
+
+CREATE INDEX idx_outbox_events_pending
+    ON outbox_events (created_at)
+    WHERE published_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE outbox_events;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chirps
+ADD COLUMN hidden_at timestamp;
+
+ALTER TABLE users
+ADD COLUMN suspended_until timestamp;
+
+CREATE TABLE reports (
+    id TEXT PRIMARY KEY,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    chirp_id TEXT not null,
+    reporter_id TEXT not null,
+    reason TEXT not null,
+    status TEXT not null CHECK (status IN ('open', 'resolved')),
+    action TEXT CHECK (action IN ('hide', 'delete', 'suspend', 'dismiss')),
+    resolved_at timestamp,
+
+    CONSTRAINT fk_reports_chirps
+        FOREIGN KEY (chirp_id)
+        REFERENCES chirps(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_reports_users
+        FOREIGN KEY (reporter_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT uq_reports_chirp_reporter
+        UNIQUE (chirp_id, reporter_id)
+);
+
+CREATE INDEX idx_reports_status_created_at
+    ON reports (status, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE reports;
+
+ALTER TABLE users
+DROP COLUMN suspended_until;
+
+ALTER TABLE chirps
+DROP COLUMN hidden_at;
+-- +goose StatementEnd
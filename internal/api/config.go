@@ -1,14 +1,79 @@
 package api
 
 import (
-	"chirpy/internal/database"
+	"chirpy/internal/health"
+	"chirpy/internal/moderation"
+	"chirpy/internal/profanity"
+	"chirpy/internal/store"
+	"chirpy/internal/stream"
+	"context"
+	"net/http"
 	"sync/atomic"
+	"time"
 )
 
+// DefaultQueryTimeout bounds DB work for routes without an override.
+const DefaultQueryTimeout = 5 * time.Second
+
 type Config struct {
-	FileserverHits atomic.Int32
-	DB             *database.Queries
+	DB             store.Store
 	Platform       string
 	JWTSecret	   string
 	PolkaKey       string
-}
\ No newline at end of file
+	Health         *health.Checker
+	// HealthToken is the Bearer token that unlocks /readyz?verbose=1;
+	// empty disables verbose readiness.
+	HealthToken string
+	// MetricsToken is the Bearer token scrapers send to GET /metrics;
+	// empty refuses every scrape.
+	MetricsToken string
+
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	ChirpMaxLength  int
+	// Moderation serves the reloadable chirp filter. Without it,
+	// Profanity is used, and without that profanity.Default().
+	Moderation *moderation.Wordlist
+	Profanity  *profanity.Filter
+	// Stream receives chirp changes for live clients; nil disables
+	// GET /api/stream/chirps and GET /api/ws.
+	Stream *stream.Hub
+	// AllowedOrigins lists the browser origins, besides the server's own,
+	// that may open GET /api/ws; "*" allows any. It mirrors the CORS
+	// allowlist.
+	AllowedOrigins []string
+
+	// QueryTimeout bounds the DB calls made while serving a request.
+	// RouteQueryTimeouts overrides it per ServeMux pattern,
+	// e.g. "GET /api/chirps".
+	QueryTimeout       time.Duration
+	RouteQueryTimeouts map[string]time.Duration
+
+	// ShuttingDown is set once graceful shutdown starts so that readiness
+	// fails while in-flight requests drain.
+	ShuttingDown atomic.Bool
+}
+
+// ProfanityFilter returns the filter applied to chirp bodies.
+func (c *Config) ProfanityFilter() *profanity.Filter {
+	if c.Moderation != nil {
+		return c.Moderation.Filter()
+	}
+	if c.Profanity != nil {
+		return c.Profanity
+	}
+	return profanity.Default()
+}
+
+// QueryContext derives the context for DB calls made while serving r. It
+// is cancelled when the client goes away or the route's timeout elapses.
+func (c *Config) QueryContext(r *http.Request) (context.Context, context.CancelFunc) {
+	timeout := c.QueryTimeout
+	if t, ok := c.RouteQueryTimeouts[r.Pattern]; ok {
+		timeout = t
+	}
+	if timeout <= 0 {
+		timeout = DefaultQueryTimeout
+	}
+	return context.WithTimeout(r.Context(), timeout)
+}
@@ -2,6 +2,8 @@ package auth
 
 import (
 	"chirpy/internal/logger"
+	"chirpy/internal/tracing"
+	"context"
 	"errors"
 	"net/http"
 	"strings"
@@ -14,12 +16,18 @@ import (
 )
 
 // HashPassword creates an Argon2id hash of the plain-text password.
-func HashPassword(password string) (string, error) {
+func HashPassword(ctx context.Context, password string) (string, error) {
+	_, span := tracing.Tracer().Start(ctx, "auth.HashPassword")
+	defer span.End()
+
 	return argon2id.CreateHash(password, argon2id.DefaultParams)
 }
 
 // CheckPasswordHash compares a plain-text password with a hash.
-func CheckPasswordHash(password, hash string) (bool, error) {
+func CheckPasswordHash(ctx context.Context, password, hash string) (bool, error) {
+	_, span := tracing.Tracer().Start(ctx, "auth.CheckPasswordHash")
+	defer span.End()
+
 	match, err := argon2id.ComparePasswordAndHash(password, hash)
 	return match, err
 }
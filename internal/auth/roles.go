@@ -0,0 +1,36 @@
+package auth
+
+import "fmt"
+
+// Role is a user's privilege level, stored in users.role.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// roleRank orders the roles; each one includes the privileges of those
+// below it.
+var roleRank = map[Role]int{
+	RoleUser:      0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+// ParseRole validates a role name.
+func ParseRole(s string) (Role, error) {
+	r := Role(s)
+	if _, ok := roleRank[r]; !ok {
+		return "", fmt.Errorf("unknown role %q (want user, moderator or admin)", s)
+	}
+	return r, nil
+}
+
+// AtLeast reports whether r grants the privileges of min. Unknown roles
+// grant nothing.
+func (r Role) AtLeast(min Role) bool {
+	rank, ok := roleRank[r]
+	return ok && rank >= roleRank[min]
+}
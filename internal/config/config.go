@@ -0,0 +1,414 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+// Config is the complete, typed server configuration. Values are merged
+// from defaults, an optional YAML or TOML file, environment variables and
+// command-line flags, in increasing order of precedence.
+type Config struct {
+	Platform  string          `yaml:"platform" toml:"platform"`
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	DB        DBConfig        `yaml:"db" toml:"db"`
+	Auth      AuthConfig      `yaml:"auth" toml:"auth"`
+	Chirps    ChirpsConfig    `yaml:"chirps" toml:"chirps"`
+	Polka     PolkaConfig     `yaml:"polka" toml:"polka"`
+	Tracing   TracingConfig   `yaml:"tracing" toml:"tracing"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Stream    StreamConfig    `yaml:"stream" toml:"stream"`
+	PubSub    PubSubConfig    `yaml:"pubsub" toml:"pubsub"`
+}
+
+type ServerConfig struct {
+	Port              int           `yaml:"port" toml:"port"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// DrainDelay keeps serving after readiness starts failing so load
+	// balancers stop routing new traffic before connections are closed.
+	DrainDelay      time.Duration `yaml:"drain_delay" toml:"drain_delay"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+
+	CORS CORSConfig `yaml:"cors" toml:"cors"`
+	// HSTSMaxAge is sent as Strict-Transport-Security; zero omits it.
+	// Enable it only when clients reach the server over HTTPS.
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age" toml:"hsts_max_age"`
+	ContentSecurityPolicy string        `yaml:"content_security_policy" toml:"content_security_policy"`
+	Compression           bool          `yaml:"compression" toml:"compression"`
+	// HealthToken lets operators read /readyz?verbose=1 by sending it as
+	// a Bearer token. Without it, verbose readiness is unavailable.
+	HealthToken string `yaml:"health_token" toml:"health_token"`
+	// MetricsToken is required as a Bearer token on /metrics. Without it,
+	// /metrics refuses every request.
+	MetricsToken string `yaml:"metrics_token" toml:"metrics_token"`
+}
+
+// CORSConfig lists the browser origins allowed to call the API. With no
+// origins, no CORS headers are sent.
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins" toml:"allowed_origins"`
+	AllowCredentials bool          `yaml:"allow_credentials" toml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age" toml:"max_age"`
+}
+
+type DBConfig struct {
+	URL                string                   `yaml:"url" toml:"url"`
+	MaxOpenConns       int                      `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns       int                      `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime    time.Duration            `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime    time.Duration            `yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+	QueryTimeout       time.Duration            `yaml:"query_timeout" toml:"query_timeout"`
+	RouteQueryTimeouts map[string]time.Duration `yaml:"route_query_timeouts" toml:"route_query_timeouts"`
+	// MigrateOnStart applies pending embedded migrations before serving.
+	MigrateOnStart bool `yaml:"migrate_on_start" toml:"migrate_on_start"`
+}
+
+type AuthConfig struct {
+	JWTSecret       string        `yaml:"jwt_secret" toml:"jwt_secret"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" toml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" toml:"refresh_token_ttl"`
+}
+
+type ChirpsConfig struct {
+	MaxLength int             `yaml:"max_length" toml:"max_length"`
+	Profanity ProfanityConfig `yaml:"profanity" toml:"profanity"`
+}
+
+// ProfanityConfig configures the chirp profanity filter.
+type ProfanityConfig struct {
+	// WordsFile holds one banned word per line; empty uses the built-in
+	// list.
+	WordsFile string `yaml:"words_file" toml:"words_file"`
+	// Strategy is mask, asterisks or reject.
+	Strategy    string `yaml:"strategy" toml:"strategy"`
+	Leetspeak   bool   `yaml:"leetspeak" toml:"leetspeak"`
+	FoldRepeats bool   `yaml:"fold_repeats" toml:"fold_repeats"`
+	// ReloadInterval is how often each instance checks the
+	// admin-managed wordlist for changes.
+	ReloadInterval time.Duration `yaml:"reload_interval" toml:"reload_interval"`
+}
+
+type PolkaConfig struct {
+	APIKey string `yaml:"api_key" toml:"api_key"`
+}
+
+type TracingConfig struct {
+	Exporter string `yaml:"exporter" toml:"exporter"`
+	File     string `yaml:"file" toml:"file"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Backend is "memory" (each instance counts separately) or
+	// "database" (instances sharing the database share the limits).
+	Backend string `yaml:"backend" toml:"backend"`
+	// TrustForwardedFor takes the client IP from X-Forwarded-For; only
+	// safe behind a proxy that sets it.
+	TrustForwardedFor bool `yaml:"trust_forwarded_for" toml:"trust_forwarded_for"`
+	// Routes maps ServeMux patterns, e.g. "POST /api/chirps", to limits.
+	Routes map[string]RateLimitRule `yaml:"routes" toml:"routes"`
+}
+
+// RateLimitRule allows Requests per Per, with bursts of up to Burst
+// (Requests when zero).
+type RateLimitRule struct {
+	Requests int           `yaml:"requests" toml:"requests"`
+	Per      time.Duration `yaml:"per" toml:"per"`
+	Burst    int           `yaml:"burst" toml:"burst"`
+}
+
+// StreamConfig tunes the live chirp stream.
+type StreamConfig struct {
+	// ReplaySize is how many recent events are kept for clients that
+	// resume with Last-Event-ID.
+	ReplaySize int `yaml:"replay_size" toml:"replay_size"`
+	// ClientBuffer is how many events may queue for one client before it
+	// is disconnected as too slow.
+	ClientBuffer int `yaml:"client_buffer" toml:"client_buffer"`
+	// Heartbeat is how often idle streams get a keepalive comment and
+	// WebSockets a ping. A socket that answers no ping for two heartbeats
+	// is closed.
+	Heartbeat time.Duration `yaml:"heartbeat" toml:"heartbeat"`
+}
+
+// PubSubConfig chooses how instances share live events and wordlist
+// edits.
+type PubSubConfig struct {
+	// Backend is "memory" (each instance on its own) or "postgres"
+	// (LISTEN/NOTIFY, for replicas sharing a Postgres database).
+	Backend string `yaml:"backend" toml:"backend"`
+}
+
+// Pub/sub backends.
+const (
+	PubSubMemory   = "memory"
+	PubSubPostgres = "postgres"
+)
+
+// Rate limit backends.
+const (
+	RateLimitMemory   = "memory"
+	RateLimitDatabase = "database"
+)
+
+// Default returns the configuration used when nothing overrides it.
+func Default() *Config {
+	return &Config{
+		Platform: "prod",
+		Server: ServerConfig{
+			Port:              8080,
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			DrainDelay:        5 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+			CORS: CORSConfig{
+				MaxAge: 10 * time.Minute,
+			},
+			Compression: true,
+		},
+		DB: DBConfig{
+			MaxOpenConns:       25,
+			MaxIdleConns:       25,
+			ConnMaxLifetime:    30 * time.Minute,
+			ConnMaxIdleTime:    5 * time.Minute,
+			QueryTimeout:       5 * time.Second,
+			RouteQueryTimeouts: map[string]time.Duration{},
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:  time.Hour,
+			RefreshTokenTTL: 60 * 24 * time.Hour,
+		},
+		Chirps: ChirpsConfig{
+			MaxLength: 140,
+			Profanity: ProfanityConfig{
+				Strategy:       "mask",
+				Leetspeak:      true,
+				FoldRepeats:    true,
+				ReloadInterval: 30 * time.Second,
+			},
+		},
+		Tracing: TracingConfig{
+			Exporter: "none",
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Backend: RateLimitMemory,
+			Routes: map[string]RateLimitRule{
+				"POST /api/chirps": {Requests: 30, Per: time.Minute},
+				"POST /api/users":  {Requests: 10, Per: time.Hour},
+				"POST /api/login":  {Requests: 10, Per: time.Minute},
+			},
+		},
+		Stream: StreamConfig{
+			ReplaySize:   1000,
+			ClientBuffer: 64,
+			Heartbeat:    15 * time.Second,
+		},
+		PubSub: PubSubConfig{
+			Backend: PubSubMemory,
+		},
+	}
+}
+
+// Addr returns the listen address for the HTTP server.
+func (c *Config) Addr() string {
+	return fmt.Sprintf(":%d", c.Server.Port)
+}
+
+// Storage drivers, chosen by the scheme of db.url.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// Driver returns the storage driver for the URL. "sqlite://" and
+// "memory://" select SQLite and the in-memory store; anything else,
+// including key=value DSNs, is Postgres.
+func (d DBConfig) Driver() string {
+	switch {
+	case strings.HasPrefix(d.URL, DriverSQLite+":"):
+		return DriverSQLite
+	case strings.HasPrefix(d.URL, DriverMemory+":"):
+		return DriverMemory
+	default:
+		return DriverPostgres
+	}
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch c.Platform {
+	case "dev", "prod":
+	default:
+		add("platform must be \"dev\" or \"prod\", got %q", c.Platform)
+	}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		add("server.port must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	for name, d := range map[string]time.Duration{
+		"server.read_header_timeout":       c.Server.ReadHeaderTimeout,
+		"server.read_timeout":              c.Server.ReadTimeout,
+		"server.write_timeout":             c.Server.WriteTimeout,
+		"server.idle_timeout":              c.Server.IdleTimeout,
+		"server.shutdown_timeout":          c.Server.ShutdownTimeout,
+		"db.query_timeout":                 c.DB.QueryTimeout,
+		"auth.access_token_ttl":            c.Auth.AccessTokenTTL,
+		"auth.refresh_token_ttl":           c.Auth.RefreshTokenTTL,
+		"chirps.profanity.reload_interval": c.Chirps.Profanity.ReloadInterval,
+		"stream.heartbeat":                 c.Stream.Heartbeat,
+	} {
+		if d <= 0 {
+			add("%s must be positive, got %s", name, d)
+		}
+	}
+	if c.Server.DrainDelay < 0 {
+		add("server.drain_delay must not be negative, got %s", c.Server.DrainDelay)
+	}
+	if c.Server.HSTSMaxAge < 0 {
+		add("server.hsts_max_age must not be negative, got %s", c.Server.HSTSMaxAge)
+	}
+	if c.Server.CORS.MaxAge < 0 {
+		add("server.cors.max_age must not be negative, got %s", c.Server.CORS.MaxAge)
+	}
+	for _, origin := range c.Server.CORS.AllowedOrigins {
+		if origin == "*" {
+			if c.Server.CORS.AllowCredentials {
+				add("server.cors.allowed_origins cannot contain \"*\" when allow_credentials is set")
+			}
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			add("server.cors.allowed_origins: %q must be scheme://host[:port]", origin)
+		}
+	}
+
+	if c.DB.URL == "" {
+		add("db.url is required (DB_URL)")
+	}
+	if c.DB.MaxOpenConns < 0 {
+		add("db.max_open_conns must not be negative, got %d", c.DB.MaxOpenConns)
+	}
+	if c.DB.MaxIdleConns < 0 {
+		add("db.max_idle_conns must not be negative, got %d", c.DB.MaxIdleConns)
+	}
+	if c.DB.MaxOpenConns > 0 && c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		add("db.max_idle_conns (%d) must not exceed db.max_open_conns (%d)", c.DB.MaxIdleConns, c.DB.MaxOpenConns)
+	}
+	for route, d := range c.DB.RouteQueryTimeouts {
+		if d <= 0 {
+			add("db.route_query_timeouts[%q] must be positive, got %s", route, d)
+		}
+	}
+
+	if c.Auth.JWTSecret == "" {
+		add("auth.jwt_secret is required (JWT_SECRET)")
+	}
+	if c.Chirps.MaxLength < 1 {
+		add("chirps.max_length must be at least 1, got %d", c.Chirps.MaxLength)
+	}
+	switch c.Chirps.Profanity.Strategy {
+	case "mask", "asterisks", "reject":
+	default:
+		add("chirps.profanity.strategy must be mask, asterisks or reject, got %q", c.Chirps.Profanity.Strategy)
+	}
+	if c.Polka.APIKey == "" {
+		add("polka.api_key is required (POLKA_KEY)")
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "stdout-file":
+		if c.Tracing.File == "" {
+			add("tracing.file is required when tracing.exporter is \"stdout-file\"")
+		}
+	default:
+		add("tracing.exporter must be none, stdout or stdout-file, got %q", c.Tracing.Exporter)
+	}
+
+	switch c.RateLimit.Backend {
+	case RateLimitMemory:
+	case RateLimitDatabase:
+		if c.RateLimit.Enabled && c.DB.Driver() == DriverMemory {
+			add("rate_limit.backend \"database\" needs a database, but db.url selects the in-memory store")
+		}
+	default:
+		add("rate_limit.backend must be memory or database, got %q", c.RateLimit.Backend)
+	}
+	for route, rule := range c.RateLimit.Routes {
+		if rule.Requests < 1 || rule.Per <= 0 || rule.Burst < 0 {
+			add("rate_limit.routes[%q] needs requests >= 1, a positive per and burst >= 0", route)
+		}
+	}
+
+	if c.Stream.ReplaySize < 0 {
+		add("stream.replay_size must not be negative, got %d", c.Stream.ReplaySize)
+	}
+	if c.Stream.ClientBuffer < 1 {
+		add("stream.client_buffer must be at least 1, got %d", c.Stream.ClientBuffer)
+	}
+
+	switch c.PubSub.Backend {
+	case PubSubMemory:
+	case PubSubPostgres:
+		if c.DB.Driver() != DriverPostgres {
+			add("pubsub.backend \"postgres\" needs db.url to select Postgres")
+		}
+	default:
+		add("pubsub.backend must be memory or postgres, got %q", c.PubSub.Backend)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of c that is safe to log.
+func (c *Config) Redacted() *Config {
+	out := *c
+	out.DB.RouteQueryTimeouts = make(map[string]time.Duration, len(c.DB.RouteQueryTimeouts))
+	for k, v := range c.DB.RouteQueryTimeouts {
+		out.DB.RouteQueryTimeouts[k] = v
+	}
+	if out.DB.URL != "" {
+		out.DB.URL = redactURL(out.DB.URL)
+	}
+	if out.Auth.JWTSecret != "" {
+		out.Auth.JWTSecret = redacted
+	}
+	if out.Polka.APIKey != "" {
+		out.Polka.APIKey = redacted
+	}
+	if out.Server.HealthToken != "" {
+		out.Server.HealthToken = redacted
+	}
+	if out.Server.MetricsToken != "" {
+		out.Server.MetricsToken = redacted
+	}
+	return &out
+}
+
+// String renders the redacted configuration as YAML.
+func (c *Config) String() string {
+	b, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("<config: %v>", err)
+	}
+	return string(b)
+}
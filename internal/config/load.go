@@ -0,0 +1,336 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Load builds the configuration from defaults, the YAML or TOML file named
+// by -config or CHIRPY_CONFIG, environment variables (via getenv), and the
+// flags in args, then validates it.
+func Load(args []string, getenv func(string) string) (*Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("chirpy", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	flags := registerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("parsing flags: %w", err)
+	}
+
+	path := getenv("CHIRPY_CONFIG")
+	if *flags.configFile != "" {
+		path = *flags.configFile
+	}
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(cfg, getenv); err != nil {
+		return nil, err
+	}
+
+	flags.apply(cfg, fs)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// loadFile reads a TOML file when path ends in .toml and YAML otherwise.
+// Unknown fields are errors in both.
+func loadFile(cfg *Config, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening config file: %w", err)
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		md, err := toml.NewDecoder(f).Decode(cfg)
+		if err != nil {
+			return fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+		if unknown := md.Undecoded(); len(unknown) > 0 {
+			return fmt.Errorf("parsing config file %s: unknown fields %v", path, unknown)
+		}
+		return nil
+	}
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func applyEnv(cfg *Config, getenv func(string) string) error {
+	var errs []error
+
+	str := func(key string, dst *string) {
+		if v := getenv(key); v != "" {
+			*dst = v
+		}
+	}
+	integer := func(key string, dst *int) {
+		if v := getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not an integer", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	duration := func(key string, dst *time.Duration) {
+		if v := getenv(key); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not a duration", key, v))
+				return
+			}
+			*dst = d
+		}
+	}
+
+	boolean := func(key string, dst *bool) {
+		if v := getenv(key); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not a boolean", key, v))
+				return
+			}
+			*dst = b
+		}
+	}
+	list := func(key string, dst *[]string) {
+		if v := getenv(key); v != "" {
+			*dst = nil
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					*dst = append(*dst, item)
+				}
+			}
+		}
+	}
+
+	str("PLATFORM", &cfg.Platform)
+
+	integer("PORT", &cfg.Server.Port)
+	duration("SERVER_READ_HEADER_TIMEOUT", &cfg.Server.ReadHeaderTimeout)
+	duration("SERVER_READ_TIMEOUT", &cfg.Server.ReadTimeout)
+	duration("SERVER_WRITE_TIMEOUT", &cfg.Server.WriteTimeout)
+	duration("SERVER_IDLE_TIMEOUT", &cfg.Server.IdleTimeout)
+	duration("SERVER_DRAIN_DELAY", &cfg.Server.DrainDelay)
+	duration("SERVER_SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout)
+	str("HEALTH_TOKEN", &cfg.Server.HealthToken)
+	str("METRICS_TOKEN", &cfg.Server.MetricsToken)
+	list("CORS_ALLOWED_ORIGINS", &cfg.Server.CORS.AllowedOrigins)
+	boolean("CORS_ALLOW_CREDENTIALS", &cfg.Server.CORS.AllowCredentials)
+	duration("CORS_MAX_AGE", &cfg.Server.CORS.MaxAge)
+	duration("SERVER_HSTS_MAX_AGE", &cfg.Server.HSTSMaxAge)
+	str("SERVER_CONTENT_SECURITY_POLICY", &cfg.Server.ContentSecurityPolicy)
+	boolean("SERVER_COMPRESSION", &cfg.Server.Compression)
+
+	str("DB_URL", &cfg.DB.URL)
+	integer("DB_MAX_OPEN_CONNS", &cfg.DB.MaxOpenConns)
+	integer("DB_MAX_IDLE_CONNS", &cfg.DB.MaxIdleConns)
+	duration("DB_CONN_MAX_LIFETIME", &cfg.DB.ConnMaxLifetime)
+	duration("DB_CONN_MAX_IDLE_TIME", &cfg.DB.ConnMaxIdleTime)
+	duration("DB_QUERY_TIMEOUT", &cfg.DB.QueryTimeout)
+	boolean("DB_MIGRATE_ON_START", &cfg.DB.MigrateOnStart)
+	if v := getenv("DB_ROUTE_QUERY_TIMEOUTS"); v != "" {
+		timeouts, err := ParseRouteTimeouts(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("DB_ROUTE_QUERY_TIMEOUTS: %w", err))
+		} else {
+			for route, d := range timeouts {
+				cfg.DB.RouteQueryTimeouts[route] = d
+			}
+		}
+	}
+
+	str("JWT_SECRET", &cfg.Auth.JWTSecret)
+	duration("ACCESS_TOKEN_TTL", &cfg.Auth.AccessTokenTTL)
+	duration("REFRESH_TOKEN_TTL", &cfg.Auth.RefreshTokenTTL)
+
+	integer("CHIRP_MAX_LENGTH", &cfg.Chirps.MaxLength)
+	str("PROFANITY_WORDS_FILE", &cfg.Chirps.Profanity.WordsFile)
+	str("PROFANITY_STRATEGY", &cfg.Chirps.Profanity.Strategy)
+	boolean("PROFANITY_LEETSPEAK", &cfg.Chirps.Profanity.Leetspeak)
+	boolean("PROFANITY_FOLD_REPEATS", &cfg.Chirps.Profanity.FoldRepeats)
+	duration("PROFANITY_RELOAD_INTERVAL", &cfg.Chirps.Profanity.ReloadInterval)
+
+	str("POLKA_KEY", &cfg.Polka.APIKey)
+
+	str("OTEL_TRACES_EXPORTER", &cfg.Tracing.Exporter)
+	str("OTEL_TRACES_FILE", &cfg.Tracing.File)
+
+	boolean("RATE_LIMIT_ENABLED", &cfg.RateLimit.Enabled)
+	str("RATE_LIMIT_BACKEND", &cfg.RateLimit.Backend)
+	boolean("RATE_LIMIT_TRUST_FORWARDED_FOR", &cfg.RateLimit.TrustForwardedFor)
+	if v := getenv("RATE_LIMITS"); v != "" {
+		rules, err := ParseRateLimits(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("RATE_LIMITS: %w", err))
+		} else {
+			for route, rule := range rules {
+				cfg.RateLimit.Routes[route] = rule
+			}
+		}
+	}
+
+	integer("STREAM_REPLAY_SIZE", &cfg.Stream.ReplaySize)
+	integer("STREAM_CLIENT_BUFFER", &cfg.Stream.ClientBuffer)
+	duration("STREAM_HEARTBEAT", &cfg.Stream.Heartbeat)
+
+	str("PUBSUB_BACKEND", &cfg.PubSub.Backend)
+
+	return errors.Join(errs...)
+}
+
+type flagValues struct {
+	configFile     *string
+	platform       *string
+	port           *int
+	dbURL          *string
+	maxOpenConns   *int
+	maxIdleConns   *int
+	queryTimeout   *time.Duration
+	migrateOnStart *bool
+	accessTTL      *time.Duration
+	refreshTTL     *time.Duration
+	chirpMaxLength *int
+	tracing        *string
+}
+
+func registerFlags(fs *flag.FlagSet) *flagValues {
+	return &flagValues{
+		configFile:     fs.String("config", "", "path to a YAML or TOML (.toml) config file"),
+		platform:       fs.String("platform", "", "dev or prod"),
+		port:           fs.Int("port", 0, "HTTP listen port"),
+		dbURL:          fs.String("db-url", "", "database connection URL"),
+		maxOpenConns:   fs.Int("db-max-open-conns", 0, "maximum open DB connections"),
+		maxIdleConns:   fs.Int("db-max-idle-conns", 0, "maximum idle DB connections"),
+		queryTimeout:   fs.Duration("db-query-timeout", 0, "default per-request DB timeout"),
+		migrateOnStart: fs.Bool("migrate-on-start", false, "apply pending migrations before serving"),
+		accessTTL:      fs.Duration("access-token-ttl", 0, "lifetime of access JWTs"),
+		refreshTTL:     fs.Duration("refresh-token-ttl", 0, "lifetime of refresh tokens"),
+		chirpMaxLength: fs.Int("chirp-max-length", 0, "maximum chirp length"),
+		tracing:        fs.String("tracing", "", "trace exporter: none, stdout or stdout-file"),
+	}
+}
+
+// apply copies only the flags that were set explicitly, so flag defaults
+// never mask file or environment values.
+func (f *flagValues) apply(cfg *Config, fs *flag.FlagSet) {
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "platform":
+			cfg.Platform = *f.platform
+		case "port":
+			cfg.Server.Port = *f.port
+		case "db-url":
+			cfg.DB.URL = *f.dbURL
+		case "db-max-open-conns":
+			cfg.DB.MaxOpenConns = *f.maxOpenConns
+		case "db-max-idle-conns":
+			cfg.DB.MaxIdleConns = *f.maxIdleConns
+		case "db-query-timeout":
+			cfg.DB.QueryTimeout = *f.queryTimeout
+		case "migrate-on-start":
+			cfg.DB.MigrateOnStart = *f.migrateOnStart
+		case "access-token-ttl":
+			cfg.Auth.AccessTokenTTL = *f.accessTTL
+		case "refresh-token-ttl":
+			cfg.Auth.RefreshTokenTTL = *f.refreshTTL
+		case "chirp-max-length":
+			cfg.Chirps.MaxLength = *f.chirpMaxLength
+		case "tracing":
+			cfg.Tracing.Exporter = *f.tracing
+		}
+	})
+}
+
+// ParseRouteTimeouts parses "GET /api/chirps=2s,POST /api/login=3s" into
+// a map keyed by ServeMux pattern.
+func ParseRouteTimeouts(s string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("entry %q must be <pattern>=<duration>", entry)
+		}
+		d, err := time.ParseDuration(entry[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("entry %q: %w", entry, err)
+		}
+		timeouts[strings.TrimSpace(entry[:i])] = d
+	}
+	return timeouts, nil
+}
+
+// ParseRateLimits parses "POST /api/chirps=30/1m,POST /api/users=5/1h"
+// into rules keyed by ServeMux pattern. Each rule allows bursts of its
+// full request count.
+func ParseRateLimits(s string) (map[string]RateLimitRule, error) {
+	rules := make(map[string]RateLimitRule)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("entry %q must be <pattern>=<requests>/<duration>", entry)
+		}
+		count, per, ok := strings.Cut(entry[i+1:], "/")
+		if !ok {
+			return nil, fmt.Errorf("entry %q must be <pattern>=<requests>/<duration>", entry)
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return nil, fmt.Errorf("entry %q: %w", entry, err)
+		}
+		d, err := time.ParseDuration(per)
+		if err != nil {
+			return nil, fmt.Errorf("entry %q: %w", entry, err)
+		}
+		rules[strings.TrimSpace(entry[:i])] = RateLimitRule{Requests: n, Per: d}
+	}
+	return rules, nil
+}
+
+var dsnPassword = regexp.MustCompile(`password=\S+`)
+
+// redactURL hides the password in a connection URL or a key=value DSN.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return dsnPassword.ReplaceAllString(raw, "password="+redacted)
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+	return u.String()
+}
@@ -6,17 +6,86 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Body      string
 	UserID    uuid.UUID
+	HiddenAt  sql.NullTime
+}
+
+type ModerationWord struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Word      string
+	Severity  string
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	Type        string
+	ChirpID     uuid.NullUUID
+	LastActorID uuid.UUID
+	ActorCount  int32
+	ReadAt      sql.NullTime
+}
+
+type NotificationActor struct {
+	NotificationID uuid.UUID
+	ActorID        uuid.UUID
+	CreatedAt      time.Time
+}
+
+type OutboxEvent struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	EventType   string
+	AggregateID uuid.UUID
+	Payload     json.RawMessage
+	Attempts    int32
+	LastError   sql.NullString
+	PublishedAt sql.NullTime
+}
+
+type Report struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	ChirpID    uuid.UUID
+	ReporterID uuid.UUID
+	Reason     string
+	Status     string
+	Action     sql.NullString
+	ResolvedAt sql.NullTime
+}
+
+type RateLimitBucket struct {
+	Key       string
+	Tokens    float64
+	UpdatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -35,4 +104,6 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	SuspendedUntil sql.NullTime
+	Role           string
 }
@@ -17,7 +17,7 @@ const createRefreshToken = `-- name: CreateRefreshToken :exec
 INSERT INTO refresh_tokens (
     token, user_id, expires_at, created_at, updated_at
 ) VALUES (
-    $1, $2, $3, NOW(), NOW()
+    $1, $2, $3, $4, $5
 )
 `
 
@@ -25,10 +25,18 @@ type CreateRefreshTokenParams struct {
 	Token     string
 	UserID    uuid.UUID
 	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) error {
-	_, err := q.db.ExecContext(ctx, createRefreshToken, arg.Token, arg.UserID, arg.ExpiresAt)
+	_, err := q.db.ExecContext(ctx, createRefreshToken,
+		arg.Token,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
 	return err
 }
 
@@ -51,18 +59,40 @@ func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshTok
 	return i, err
 }
 
+const revokeAllRefreshTokensForUser = `-- name: RevokeAllRefreshTokensForUser :execrows
+UPDATE refresh_tokens
+SET revoked_at = $2, updated_at = $3
+WHERE user_id = $1
+  AND revoked_at IS NULL
+`
+
+type RevokeAllRefreshTokensForUserParams struct {
+	UserID    uuid.UUID
+	RevokedAt sql.NullTime
+	UpdatedAt time.Time
+}
+
+func (q *Queries) RevokeAllRefreshTokensForUser(ctx context.Context, arg RevokeAllRefreshTokensForUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeAllRefreshTokensForUser, arg.UserID, arg.RevokedAt, arg.UpdatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens
-SET revoked_at = $1, updated_at = NOW()
-WHERE token = $2
+SET revoked_at = $1, updated_at = $2
+WHERE token = $3
 `
 
 type RevokeRefreshTokenParams struct {
 	RevokedAt sql.NullTime
+	UpdatedAt time.Time
 	Token     string
 }
 
 func (q *Queries) RevokeRefreshToken(ctx context.Context, arg RevokeRefreshTokenParams) error {
-	_, err := q.db.ExecContext(ctx, revokeRefreshToken, arg.RevokedAt, arg.Token)
+	_, err := q.db.ExecContext(ctx, revokeRefreshToken, arg.RevokedAt, arg.UpdatedAt, arg.Token)
 	return err
 }
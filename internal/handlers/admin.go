@@ -2,56 +2,143 @@ package handlers
 
 import (
 	"chirpy/internal/api"
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
 	"chirpy/internal/logger"
+	"chirpy/internal/middleware"
+	"chirpy/internal/models"
 	"chirpy/internal/utils"
 	"context"
-	"fmt"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
+
+	"github.com/google/uuid"
 )
 
-func HandleMetrics(cfg *api.Config) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		logger.Logger.Infow("Admin metrics requested",
-			"remote_addr", r.RemoteAddr,
-			"user_agent", r.UserAgent(),
-		)
+type actorKey struct{}
 
-		hits := cfg.FileserverHits.Load()
-		html := fmt.Sprintf(`
-		<html>
-		<body>
-			<h1>Welcome, Chirpy Admin</h1>
-			<p>Chirpy has been visited %d times!</p>
-		</body>
-		</html>`, hits)
+// RequireRole only lets through callers whose access token belongs to a
+// user holding at least min and not currently suspended. The role is read
+// from the database on every request, so demotions apply immediately
+// rather than when the token expires.
+func RequireRole(cfg *api.Config, min auth.Role) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log := logger.FromContext(r.Context())
 
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(html))
+			user, ok := activeCaller(cfg, w, r)
+			if !ok {
+				return
+			}
+			if user.ID == uuid.Nil {
+				utils.RespondWithError(w, http.StatusUnauthorized, "Invalid or expired token")
+				return
+			}
+			if !auth.Role(user.Role).AtLeast(min) {
+				log.Warnw("Insufficient role",
+					"user_id", user.ID,
+					"role", user.Role,
+					"required", min,
+					"path", r.URL.Path,
+				)
+				utils.RespondWithError(w, http.StatusForbidden, "Forbidden")
+				return
+			}
 
-		logger.Logger.Infow("Admin metrics served", "hits", hits)
+			log = log.With("actor_id", user.ID, "actor_role", user.Role)
+			ctx := logger.NewContext(r.Context(), log)
+			ctx = context.WithValue(ctx, actorKey{}, user)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
 	}
 }
 
+// RequireActive only lets through callers whose access token belongs to a
+// user who is not currently suspended. Suspension does not revoke access
+// tokens already issued, so every route that writes on the caller's
+// behalf sits behind this check. A token for an unknown user is passed
+// on, leaving next to answer as it would without this check.
+func RequireActive(cfg *api.Config) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := activeCaller(cfg, w, r); ok {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// activeCaller returns the user behind the request's access token, read
+// fresh from the database, or the zero User if there is no such user. It
+// answers 401 for a bad token and 403 for a suspended user, and then
+// reports false.
+func activeCaller(cfg *api.Config, w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	log := logger.FromContext(r.Context())
+
+	userID, ok := authenticate(cfg, w, r)
+	if !ok {
+		return database.User{}, false
+	}
+
+	ctx, cancel := cfg.QueryContext(r)
+	user, err := cfg.DB.GetUserByID(ctx, userID)
+	cancel()
+	if err != nil {
+		if queryAborted(w, r, err) {
+			return database.User{}, false
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.User{}, true
+		}
+		log.Errorw("Failed to look up caller", "user_id", userID, "error", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Server error")
+		return database.User{}, false
+	}
+
+	if isSuspended(user, time.Now()) {
+		log.Warnw("Suspended user refused",
+			"user_id", user.ID,
+			"path", r.URL.Path,
+		)
+		utils.RespondWithError(w, http.StatusForbidden, suspendedMessage(user))
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// actor returns the user admitted by RequireRole.
+func actor(r *http.Request) database.User {
+	u, _ := r.Context().Value(actorKey{}).(database.User)
+	return u
+}
+
 func HandleReset(cfg *api.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger.Logger.Infow("Reset request received",
+		log := logger.FromContext(r.Context())
+		log.Infow("Reset request received",
 			"platform", cfg.Platform,
 			"remote_addr", r.RemoteAddr,
 		)
 
 		if cfg.Platform != "dev" {
-			logger.Logger.Warnw("Reset attempted in non-dev environment",
+			log.Warnw("Reset attempted in non-dev environment",
 				"platform", cfg.Platform,
 			)
 			utils.RespondWithError(w, http.StatusForbidden, "Reset only allowed in dev")
 			return
 		}
 
-		ctx := context.Background()
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
 
 		if err := cfg.DB.DeleteAllChirps(ctx); err != nil {
-			logger.Logger.Errorw("Failed to delete chirps during reset",
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to delete chirps during reset",
 				"error", err,
 			)
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete chirps")
@@ -59,18 +146,79 @@ func HandleReset(cfg *api.Config) http.HandlerFunc {
 		}
 
 		if err := cfg.DB.DeleteAllUsers(ctx); err != nil {
-			logger.Logger.Errorw("Failed to delete users during reset",
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to delete users during reset",
 				"error", err,
 			)
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete users")
 			return
 		}
 
-		cfg.FileserverHits.Store(0)
-
 		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("Users and hits reset"))
+		_, _ = w.Write([]byte("Users and chirps reset"))
+
+		log.Infow("Reset completed successfully")
+	}
+}
+func HandleUpdateUserRole(cfg *api.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+
+		// === 1. Validate input ===
+		userID, err := uuid.Parse(r.PathValue("userID"))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+			return
+		}
+		var req models.UpdateRoleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		role, err := auth.ParseRole(req.Role)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Role must be user, moderator or admin")
+			return
+		}
+		// Keeps the last admin from locking everyone out by accident.
+		if userID == actor(r).ID {
+			utils.RespondWithError(w, http.StatusBadRequest, "Cannot change your own role")
+			return
+		}
+
+		// === 2. Update ===
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		user, err := cfg.DB.UpdateUserRole(ctx, database.UpdateUserRoleParams{
+			ID:        userID,
+			Role:      string(role),
+			UpdatedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			if errors.Is(err, sql.ErrNoRows) {
+				utils.RespondWithError(w, http.StatusNotFound, "User not found")
+				return
+			}
+			log.Errorw("Failed to update role", "user_id", userID, "error", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update role")
+			return
+		}
 
-		logger.Logger.Infow("Reset completed successfully")
+		log.Infow("User role changed", "user_id", user.ID, "role", user.Role)
+		resp := models.UserRoleResponse{
+			ID:        user.ID,
+			Email:     user.Email,
+			UpdatedAt: user.UpdatedAt.Format(time.RFC3339),
+			Role:      user.Role,
+		}
+		if user.SuspendedUntil.Valid {
+			resp.SuspendedUntil = user.SuspendedUntil.Time.Format(time.RFC3339)
+		}
+		utils.RespondWithJSON(w, http.StatusOK, resp)
 	}
-}
\ No newline at end of file
+}
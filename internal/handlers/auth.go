@@ -5,76 +5,110 @@ import (
 	"chirpy/internal/api"
 	"chirpy/internal/auth"
 	"chirpy/internal/logger"
+	"chirpy/internal/metrics"
 	"chirpy/internal/models"
 	"chirpy/internal/utils"
 	"chirpy/internal/database"
-	"context"
 	"encoding/json"
 	"database/sql"
 	"net/http"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 
 func HandleLogin(cfg *api.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger.Logger.Infow("Login attempt", "path", r.URL.Path)
+		log := logger.FromContext(r.Context())
+		log.Infow("Login attempt", "path", r.URL.Path)
 
 		var req models.LoginRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			logger.Logger.Warnw("Invalid login JSON", "error", err)
+			log.Warnw("Invalid login JSON", "error", err)
+			metrics.LoginsTotal.WithLabelValues(metrics.LoginFailure).Inc()
 			utils.RespondWithError(w, http.StatusBadRequest, "Invalid JSON")
 			return
 		}
 
 		if req.Email == "" || req.Password == "" {
+			metrics.LoginsTotal.WithLabelValues(metrics.LoginFailure).Inc()
 			utils.RespondWithError(w, http.StatusBadRequest, "Email and password required")
 			return
 		}
 
-		ctx := context.Background()
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
 		user, err := cfg.DB.GetUserByEmail(ctx, req.Email)
 		if err != nil {
-			logger.Logger.Infow("Login failed: user not found or DB error", "email", req.Email)
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Infow("Login failed: user not found or DB error", "email", req.Email)
+			metrics.LoginsTotal.WithLabelValues(metrics.LoginFailure).Inc()
 			utils.RespondWithError(w, http.StatusUnauthorized, "Incorrect email or password")
 			return
 		}
 
-		match, err := auth.CheckPasswordHash(req.Password, user.HashedPassword)
+		match, err := auth.CheckPasswordHash(r.Context(), req.Password, user.HashedPassword)
 		if err != nil {
-			logger.Logger.Errorw("Password check error", "error", err)
+			log.Errorw("Password check error", "error", err)
+			metrics.LoginsTotal.WithLabelValues(metrics.LoginFailure).Inc()
 			utils.RespondWithError(w, http.StatusInternalServerError, "Authentication error")
 			return
 		}
 		if !match {
-			logger.Logger.Infow("Login failed: wrong password", "email", req.Email)
+			log.Infow("Login failed: wrong password", "email", req.Email)
+			metrics.LoginsTotal.WithLabelValues(metrics.LoginFailure).Inc()
 			utils.RespondWithError(w, http.StatusUnauthorized, "Incorrect email or password")
 			return
 		}
+		if isSuspended(user, time.Now()) {
+			log.Infow("Login refused: account suspended", "user_id", user.ID)
+			metrics.LoginsTotal.WithLabelValues(metrics.LoginFailure).Inc()
+			utils.RespondWithError(w, http.StatusForbidden, suspendedMessage(user))
+			return
+		}
+
+		// Count before issuing tokens, so a failure leaves no session behind.
+		unread, err := cfg.DB.CountUnreadNotifications(ctx, user.ID)
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to count unread notifications", "user_id", user.ID, "error", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to log in")
+			return
+		}
 		
 		// Generate JWT
-		accessToken, err := auth.MakeJWT(user.ID, cfg.JWTSecret, time.Hour)
+		accessToken, err := auth.MakeJWT(user.ID, cfg.JWTSecret, cfg.AccessTokenTTL)
 		if err != nil {
-			logger.Logger.Errorw("Token Creation failed","error", err)
+			log.Errorw("Token Creation failed","error", err)
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create token")
 			return
 		}
 
 		refreshToken, err := auth.MakeRefreshToken()
 		if err != nil {
-			logger.Logger.Errorw("Failed to generate refresh token", "error", err)
+			log.Errorw("Failed to generate refresh token", "error", err)
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create refresh token")
 			return
 		}
 
-		expiresAt := time.Now().Add(60 * 24 * time.Hour)
+		now := time.Now().UTC()
 		err = cfg.DB.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
 			Token:     refreshToken,
 			UserID:    user.ID,
-			ExpiresAt: expiresAt,
+			ExpiresAt: now.Add(cfg.RefreshTokenTTL),
+			CreatedAt: now,
+			UpdatedAt: now,
 		})
 		if err != nil {
-			logger.Logger.Errorw("Failed to save refresh token", "error", err)
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to save refresh token", "error", err)
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create session")
 			return
 		}
@@ -87,50 +121,76 @@ func HandleLogin(cfg *api.Config) http.HandlerFunc {
 			Token:     accessToken,
 			RefreshToken: refreshToken,
 			IsChirpyRed: user.IsChirpyRed,
+			Role:        user.Role,
+			UnreadNotifications: unread,
 		}
 
-		logger.Logger.Infow("Login successful", "user_id", user.ID, "email", user.Email)
+		metrics.LoginsTotal.WithLabelValues(metrics.LoginSuccess).Inc()
+		log.Infow("Login successful", "user_id", user.ID, "email", user.Email)
 		utils.RespondWithJSON(w, http.StatusOK, resp)
 	}
 }
 
 func HandleTokenRefresh(cfg *api.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
 		tokenStr, err := auth.GetBearerToken(r.Header)
 		if err != nil {
 			utils.RespondWithError(w, http.StatusUnauthorized, "Missing or invalid Authorization header")
 			return
 		}
 
-		ctx := context.Background()
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
 		rt, err := cfg.DB.GetRefreshToken(ctx, tokenStr)
 		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
 			if err == sql.ErrNoRows {
-				logger.Logger.Infow("Refresh token not found", "token_preview", auth.TruncateToken(tokenStr))
+				log.Infow("Refresh token not found", "token_preview", auth.TruncateToken(tokenStr))
 				utils.RespondWithError(w, http.StatusUnauthorized, "Invalid refresh token")
 				return
 			}
-			logger.Logger.Errorw("DB error looking up refresh token", "error", err)
+			log.Errorw("DB error looking up refresh token", "error", err)
 			utils.RespondWithError(w, http.StatusInternalServerError, "Server error")
 			return
 		}
 		// Check if revoked
 		if rt.RevokedAt.Valid {
-			logger.Logger.Infow("Refresh token revoked", "token_preview", auth.TruncateToken(tokenStr))
+			log.Infow("Refresh token revoked", "token_preview", auth.TruncateToken(tokenStr))
 			utils.RespondWithError(w, http.StatusUnauthorized, "Token revoked")
 			return
 		}
 
 			// Check expiration
 		if time.Now().After(rt.ExpiresAt) {
-			logger.Logger.Infow("Refresh token expired", "expires_at", rt.ExpiresAt)
+			log.Infow("Refresh token expired", "expires_at", rt.ExpiresAt)
 			utils.RespondWithError(w, http.StatusUnauthorized, "Token expired")
 			return
 		}
+
+		// Suspension revokes sessions, but a token issued afterwards or
+		// restored by hand must not outlive it either.
+		user, err := cfg.DB.GetUserByID(ctx, rt.UserID)
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("DB error looking up token owner", "user_id", rt.UserID, "error", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Server error")
+			return
+		}
+		if isSuspended(user, time.Now()) {
+			log.Infow("Refresh refused: account suspended", "user_id", user.ID)
+			utils.RespondWithError(w, http.StatusForbidden, suspendedMessage(user))
+			return
+		}
+
 		// Generate new access token
-		accessToken, err := auth.MakeJWT(rt.UserID, cfg.JWTSecret, time.Hour)
+		accessToken, err := auth.MakeJWT(rt.UserID, cfg.JWTSecret, cfg.AccessTokenTTL)
 		if err != nil {
-			logger.Logger.Errorw("Failed to create access token", "error", err)
+			log.Errorw("Failed to create access token", "error", err)
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create token")
 			return
 		}
@@ -141,7 +201,7 @@ func HandleTokenRefresh(cfg *api.Config) http.HandlerFunc {
 			Token: accessToken,
 		}
 
-		logger.Logger.Infow("Access token refreshed",
+		log.Infow("Access token refreshed",
 			"user_id", rt.UserID,
 		)
 
@@ -151,39 +211,74 @@ func HandleTokenRefresh(cfg *api.Config) http.HandlerFunc {
 
 func HandleTokenRevoke(cfg *api.Config) http.HandlerFunc {
 	return func (w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
 		tokenStr, err := auth.GetBearerToken(r.Header)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusUnauthorized, "Missing or invalid Authorization header")
 		return
 	}
 
-	ctx := context.Background()
+	ctx, cancel := cfg.QueryContext(r)
+	defer cancel()
 	_, err = cfg.DB.GetRefreshToken(ctx, tokenStr)
 	if err != nil {
+		if queryAborted(w, r, err) {
+			return
+		}
 		if err == sql.ErrNoRows {
 			// Still respond 204 — idempotent
-			logger.Logger.Infow("Attempt to revoke non-existent token", "token_preview", auth.TruncateToken(tokenStr))
+			log.Infow("Attempt to revoke non-existent token", "token_preview", auth.TruncateToken(tokenStr))
 		} else {
-			logger.Logger.Errorw("DB error checking token", "error", err)
+			log.Errorw("DB error checking token", "error", err)
 		}
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
 	// Revoke it
+	now := time.Now().UTC()
 	err = cfg.DB.RevokeRefreshToken(ctx, database.RevokeRefreshTokenParams{
-		RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		RevokedAt: sql.NullTime{Time: now, Valid: true},
+		UpdatedAt: now,
 		Token:     tokenStr,
 
 	})
 	if err != nil {
-		logger.Logger.Errorw("Failed to revoke token", "error", err)
+		if queryAborted(w, r, err) {
+			return
+		}
+		log.Errorw("Failed to revoke token", "error", err)
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to revoke")
 		return
 	}
 
-	logger.Logger.Infow("Refresh token revoked", "token_preview", auth.TruncateToken(tokenStr))
+	log.Infow("Refresh token revoked", "token_preview", auth.TruncateToken(tokenStr))
 
 	w.WriteHeader(http.StatusNoContent)
 	} 
-}
\ No newline at end of file
+}
+
+// authenticate returns the user behind the request's access token, or
+// answers 401 and reports false.
+func authenticate(cfg *api.Config, w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	tokenStr, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Missing or invalid Authorization header")
+		return uuid.Nil, false
+	}
+	userID, err := auth.ValidateJWT(tokenStr, cfg.JWTSecret)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Invalid or expired token")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// isSuspended reports whether u is serving a suspension at now.
+func isSuspended(u database.User, now time.Time) bool {
+	return u.SuspendedUntil.Valid && now.Before(u.SuspendedUntil.Time)
+}
+
+func suspendedMessage(u database.User) string {
+	return "Account suspended until " + u.SuspendedUntil.Time.UTC().Format(time.RFC3339)
+}
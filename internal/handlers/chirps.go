@@ -5,14 +5,18 @@ import (
 	"chirpy/internal/auth"
 	"chirpy/internal/database"
 	"chirpy/internal/logger"
+	"chirpy/internal/metrics"
 	"chirpy/internal/models"
+	"chirpy/internal/outbox"
+	"chirpy/internal/profanity"
+	"chirpy/internal/store"
 	"chirpy/internal/utils"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"sort"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,7 +24,8 @@ import (
 
 func HandleCreateChirp(cfg *api.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger.Logger.Infow("Create chirp request",
+		log := logger.FromContext(r.Context())
+		log.Infow("Create chirp request",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"remote_addr", r.RemoteAddr,
@@ -33,7 +38,7 @@ func HandleCreateChirp(cfg *api.Config) http.HandlerFunc {
 
 		tokenStr, err := auth.GetBearerToken(r.Header)
 		if err != nil {
-			logger.Logger.Warnw("Missing or malformed Authorization header",
+			log.Warnw("Missing or malformed Authorization header",
 				"error", err,
 			)
 			utils.RespondWithError(w, http.StatusUnauthorized, "Missing or invalid Authorization header")
@@ -47,15 +52,15 @@ func HandleCreateChirp(cfg *api.Config) http.HandlerFunc {
 
 		var req models.ChirpRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			logger.Logger.Warnw("Invalid JSON payload for chirp creation",
+			log.Warnw("Invalid JSON payload for chirp creation",
 				"error", err,
 			)
 			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 			return
 		}
 
-		if len(req.Body) > 140 {
-			logger.Logger.Infow("Chirp rejected – too long",
+		if len(req.Body) > cfg.ChirpMaxLength {
+			log.Infow("Chirp rejected – too long",
 				"length", len(req.Body),
 				"user_id", req.UserID,
 			)
@@ -63,30 +68,66 @@ func HandleCreateChirp(cfg *api.Config) http.HandlerFunc {
 			return
 		}
 
-		cleaned := utils.CleanProfanity(req.Body)
+		cleaned, err := cfg.ProfanityFilter().Clean(req.Body)
+		if errors.Is(err, profanity.ErrProfane) {
+			log.Infow("Chirp rejected – prohibited language",
+				"user_id", userID,
+			)
+			utils.RespondWithError(w, http.StatusBadRequest, "Chirp contains prohibited language")
+			return
+		}
 		if cleaned != req.Body {
-			logger.Logger.Infow("Profanity filtered",
+			log.Infow("Profanity filtered",
 				"original", req.Body,
 				"cleaned", cleaned,
 				"user_id", req.UserID,
 			)
 		}
 
-		ctx := context.Background()
-		chirp, err := cfg.DB.CreateChirps(ctx, database.CreateChirpsParams{
-			Body:   cleaned,
-			UserID: userID,
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		var (
+			chirp    database.Chirp
+			mentions []database.Notification
+		)
+		err = cfg.DB.InTx(ctx, func(tx store.Store) error {
+			var err error
+			now := time.Now().UTC()
+			chirp, err = tx.CreateChirps(ctx, database.CreateChirpsParams{
+				ID:        uuid.New(),
+				CreatedAt: now,
+				UpdatedAt: now,
+				Body:      cleaned,
+				UserID:    userID,
+			})
+			if err != nil {
+				return err
+			}
+			err = outbox.Enqueue(ctx, tx, outbox.EventChirpCreated, chirp.ID, outbox.ChirpPayload{
+				ChirpID:   chirp.ID,
+				UserID:    chirp.UserID,
+				Body:      chirp.Body,
+				CreatedAt: chirp.CreatedAt,
+			})
+			if err != nil {
+				return err
+			}
+			mentions, err = recordMentions(ctx, tx, chirp)
+			return err
 		})
 		if err != nil {
-			if strings.Contains(err.Error(), "foreign key") {
-				logger.Logger.Warnw("Invalid user_id supplied",
+			if queryAborted(w, r, err) {
+				return
+			}
+			if store.IsForeignKeyViolation(err) {
+				log.Warnw("Invalid user_id supplied",
 					"user_id", req.UserID,
 					"error", err,
 				)
 				utils.RespondWithError(w, http.StatusBadRequest, "Invalid user_id")
 				return
 			}
-			logger.Logger.Errorw("Failed to create chirp in DB",
+			log.Errorw("Failed to create chirp in DB",
 				"error", err,
 				"user_id", req.UserID,
 			)
@@ -94,6 +135,10 @@ func HandleCreateChirp(cfg *api.Config) http.HandlerFunc {
 			return
 		}
 
+		metrics.ChirpsCreatedTotal.Inc()
+		publishChirp(cfg, outbox.EventChirpCreated, chirp)
+		publishNotifications(cfg, mentions)
+
 		resp := models.ChirpResponse{
 			ID:        chirp.ID,
 			CreatedAt: chirp.CreatedAt.Format(time.RFC3339),
@@ -102,7 +147,7 @@ func HandleCreateChirp(cfg *api.Config) http.HandlerFunc {
 			UserID:    chirp.UserID,
 		}
 
-		logger.Logger.Infow("Chirp created successfully",
+		log.Infow("Chirp created successfully",
 			"chirp_id", chirp.ID,
 			"user_id", chirp.UserID,
 		)
@@ -113,7 +158,8 @@ func HandleCreateChirp(cfg *api.Config) http.HandlerFunc {
 
 func HandleGetAllChirps(cfg *api.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger.Logger.Infow("Get all chirps request",
+		log := logger.FromContext(r.Context())
+		log.Infow("Get all chirps request",
 			"path", r.URL.Path,
 			"remote_addr", r.RemoteAddr,
 		)
@@ -131,7 +177,8 @@ func HandleGetAllChirps(cfg *api.Config) http.HandlerFunc {
 
 		
 
-		ctx := context.Background()
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
 
 		var dbChirps []database.Chirp
 		var err error
@@ -148,13 +195,39 @@ func HandleGetAllChirps(cfg *api.Config) http.HandlerFunc {
 		}
 
 		if err != nil {
-			logger.Logger.Errorw("Failed to fetch chirps from DB",
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to fetch chirps from DB",
 				"error", err,
 			)
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirps")
 			return
 		}
 
+		// Hidden chirps are only listed for their author; blocked and
+		// muted authors are left out for signed-in viewers.
+		viewer := viewerID(cfg, r)
+		excluded, err := hiddenAuthors(ctx, cfg, viewer)
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to load blocks and mutes",
+				"user_id", viewer,
+				"error", err,
+			)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirps")
+			return
+		}
+		visible := dbChirps[:0]
+		for _, c := range dbChirps {
+			if (!c.HiddenAt.Valid || c.UserID == viewer) && excluded[c.UserID] == "" {
+				visible = append(visible, c)
+			}
+		}
+		dbChirps = visible
+
 		sort.Slice(dbChirps, func(i, j int) bool {
 		if sortOrder == "desc" {
 			if dbChirps[i].CreatedAt.Equal(dbChirps[j].CreatedAt) {
@@ -171,16 +244,10 @@ func HandleGetAllChirps(cfg *api.Config) http.HandlerFunc {
 
 		chirps := make([]models.ChirpResponse, len(dbChirps))
 		for i, c := range dbChirps {
-			chirps[i] = models.ChirpResponse{
-				ID:        c.ID,
-				CreatedAt: c.CreatedAt.Format(time.RFC3339),
-				UpdatedAt: c.UpdatedAt.Format(time.RFC3339),
-				Body:      c.Body,
-				UserID:    c.UserID,
-			}
+			chirps[i] = chirpResponse(c)
 		}
 
-		logger.Logger.Infow("Returned all chirps",
+		log.Infow("Returned all chirps",
 			"count", len(chirps),
 		)
 
@@ -190,7 +257,8 @@ func HandleGetAllChirps(cfg *api.Config) http.HandlerFunc {
 
 func HandleGetChirpByID(cfg *api.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger.Logger.Infow("Get chirp by ID request",
+		log := logger.FromContext(r.Context())
+		log.Infow("Get chirp by ID request",
 			"path", r.URL.Path,
 			"remote_addr", r.RemoteAddr,
 		)
@@ -202,14 +270,14 @@ func HandleGetChirpByID(cfg *api.Config) http.HandlerFunc {
 
 		idStr := r.PathValue("chirpID")
 		if idStr == "" {
-			logger.Logger.Warnw("Missing chirp ID in path")
+			log.Warnw("Missing chirp ID in path")
 			utils.RespondWithError(w, http.StatusBadRequest, "Missing chirp ID")
 			return
 		}
 
 		chirpID, err := uuid.Parse(idStr)
 		if err != nil {
-			logger.Logger.Warnw("Invalid chirp ID format",
+			log.Warnw("Invalid chirp ID format",
 				"id", idStr,
 				"error", err,
 			)
@@ -217,17 +285,21 @@ func HandleGetChirpByID(cfg *api.Config) http.HandlerFunc {
 			return
 		}
 
-		ctx := context.Background()
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
 		dbChirp, err := cfg.DB.GetChirpByID(ctx, chirpID)
 		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
 			if err == sql.ErrNoRows {
-				logger.Logger.Infow("Chirp not found",
+				log.Infow("Chirp not found",
 					"chirp_id", chirpID,
 				)
 				utils.RespondWithError(w, http.StatusNotFound, "Chirp not found")
 				return
 			}
-			logger.Logger.Errorw("DB error while fetching chirp",
+			log.Errorw("DB error while fetching chirp",
 				"chirp_id", chirpID,
 				"error", err,
 			)
@@ -235,15 +307,38 @@ func HandleGetChirpByID(cfg *api.Config) http.HandlerFunc {
 			return
 		}
 
-		resp := models.ChirpResponse{
-			ID:        dbChirp.ID,
-			CreatedAt: dbChirp.CreatedAt.Format(time.RFC3339),
-			UpdatedAt: dbChirp.UpdatedAt.Format(time.RFC3339),
-			Body:      dbChirp.Body,
-			UserID:    dbChirp.UserID,
+		viewer := viewerID(cfg, r)
+		if dbChirp.HiddenAt.Valid && dbChirp.UserID != viewer {
+			log.Infow("Hidden chirp requested by someone other than its author",
+				"chirp_id", chirpID,
+			)
+			utils.RespondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
 		}
+		// Muting only thins out lists; a block hides the chirp outright.
+		excluded, err := hiddenAuthors(ctx, cfg, viewer)
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to load blocks and mutes",
+				"user_id", viewer,
+				"error", err,
+			)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve chirp")
+			return
+		}
+		if excluded[dbChirp.UserID] == "block" {
+			log.Infow("Chirp hidden by a block",
+				"chirp_id", chirpID,
+			)
+			utils.RespondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+
+		resp := chirpResponse(dbChirp)
 
-		logger.Logger.Infow("Chirp retrieved",
+		log.Infow("Chirp retrieved",
 			"chirp_id", dbChirp.ID,
 			"user_id", dbChirp.UserID,
 		)
@@ -254,17 +349,18 @@ func HandleGetChirpByID(cfg *api.Config) http.HandlerFunc {
 
 func HandleDeleteChirp(cfg *api.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
 		// === 1. Extract chirp ID from path ===
 		chirpIDStr := r.PathValue("chirpID")
 		if chirpIDStr == "" {
-			logger.Logger.Warnw("Missing chirp ID in path")
+			log.Warnw("Missing chirp ID in path")
 			utils.RespondWithError(w, http.StatusBadRequest, "Missing chirp ID")
 			return
 		}
 
 		chirpID, err := uuid.Parse(chirpIDStr)
 		if err != nil {
-			logger.Logger.Warnw("Invalid chirp ID format",
+			log.Warnw("Invalid chirp ID format",
 				"chirp_id", chirpIDStr,
 				"error", err,
 			)
@@ -275,7 +371,7 @@ func HandleDeleteChirp(cfg *api.Config) http.HandlerFunc {
 		// === 2. Authenticate user via JWT ===
 		tokenStr, err := auth.GetBearerToken(r.Header)
 		if err != nil {
-			logger.Logger.Warnw("Missing or malformed Authorization header",
+			log.Warnw("Missing or malformed Authorization header",
 				"error", err,
 			)
 			utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
@@ -284,7 +380,7 @@ func HandleDeleteChirp(cfg *api.Config) http.HandlerFunc {
 
 		userID, err := auth.ValidateJWT(tokenStr, cfg.JWTSecret)
 		if err != nil {
-			logger.Logger.Infow("Invalid or expired access token",
+			log.Infow("Invalid or expired access token",
 				"error", err,
 				"token_preview", auth.TruncateToken(tokenStr),
 			)
@@ -293,18 +389,22 @@ func HandleDeleteChirp(cfg *api.Config) http.HandlerFunc {
 		}
 
 		// === 3. Fetch chirp with author info ===
-		ctx := context.Background()
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
 		chirp, err := cfg.DB.GetChirpByID(ctx, chirpID)
 		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
 			if err == sql.ErrNoRows {
-				logger.Logger.Infow("Chirp not found for deletion",
+				log.Infow("Chirp not found for deletion",
 					"chirp_id", chirpID,
 					"user_id", userID,
 				)
 				utils.RespondWithError(w, http.StatusNotFound, "Chirp not found")
 				return
 			}
-			logger.Logger.Errorw("Database error fetching chirp",
+			log.Errorw("Database error fetching chirp",
 				"chirp_id", chirpID,
 				"error", err,
 			)
@@ -314,7 +414,7 @@ func HandleDeleteChirp(cfg *api.Config) http.HandlerFunc {
 
 		// === 4. Authorization: Only author can delete ===
 		if chirp.UserID != userID {
-			logger.Logger.Warnw("User attempted to delete another user's chirp",
+			log.Warnw("User attempted to delete another user's chirp",
 				"chirp_id", chirp.ID,
 				"requesting_user_id", userID,
 				"chirp_owner_id", chirp.UserID,
@@ -323,10 +423,22 @@ func HandleDeleteChirp(cfg *api.Config) http.HandlerFunc {
 			return
 		}
 
-		// === 5. Delete chirp ===
-		err = cfg.DB.DeleteChirp(ctx, chirpID)
+		// === 5. Delete chirp and record the event atomically ===
+		err = cfg.DB.InTx(ctx, func(tx store.Store) error {
+			if err := tx.DeleteChirp(ctx, chirpID); err != nil {
+				return err
+			}
+			return outbox.Enqueue(ctx, tx, outbox.EventChirpDeleted, chirp.ID, outbox.ChirpPayload{
+				ChirpID:   chirp.ID,
+				UserID:    chirp.UserID,
+				CreatedAt: chirp.CreatedAt,
+			})
+		})
 		if err != nil {
-			logger.Logger.Errorw("Failed to delete chirp from database",
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to delete chirp from database",
 				"chirp_id", chirpID,
 				"user_id", userID,
 				"error", err,
@@ -335,12 +447,64 @@ func HandleDeleteChirp(cfg *api.Config) http.HandlerFunc {
 			return
 		}
 
+		publishChirp(cfg, outbox.EventChirpDeleted, chirp)
+
 		// === 6. Success: 204 No Content ===
-		logger.Logger.Infow("Chirp deleted successfully",
+		log.Infow("Chirp deleted successfully",
 			"chirp_id", chirpID,
 			"user_id", userID,
 		)
 
 		w.WriteHeader(http.StatusNoContent)
 	}
-}
\ No newline at end of file
+}
+
+// viewerID returns the user behind a valid access token, or uuid.Nil.
+// Read-only routes serve anonymous callers, so a missing or invalid token
+// is not an error there.
+func viewerID(cfg *api.Config, r *http.Request) uuid.UUID {
+	tokenStr, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.Nil
+	}
+	userID, err := auth.ValidateJWT(tokenStr, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil
+	}
+	return userID
+}
+
+// hiddenAuthors maps the authors viewer should not see to why: "block"
+// for blocks in either direction, "mute" for users viewer muted. A block
+// wins over a mute. Anonymous viewers get an empty map.
+func hiddenAuthors(ctx context.Context, cfg *api.Config, viewer uuid.UUID) (map[uuid.UUID]string, error) {
+	if viewer == uuid.Nil {
+		return nil, nil
+	}
+	rows, err := cfg.DB.ListHiddenAuthors(ctx, database.ListHiddenAuthorsParams{
+		BlockerID: viewer,
+		BlockedID: viewer,
+		MuterID:   viewer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[uuid.UUID]string, len(rows))
+	for _, row := range rows {
+		if out[row.UserID] != "block" {
+			out[row.UserID] = row.Kind
+		}
+	}
+	return out, nil
+}
+
+func chirpResponse(c database.Chirp) models.ChirpResponse {
+	return models.ChirpResponse{
+		ID:        c.ID,
+		CreatedAt: c.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: c.UpdatedAt.Format(time.RFC3339),
+		Body:      c.Body,
+		UserID:    c.UserID,
+		Hidden:    c.HiddenAt.Valid,
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"chirpy/internal/logger"
+	"chirpy/internal/metrics"
+	"chirpy/internal/utils"
+	"context"
+	"errors"
+	"net/http"
+)
+
+// queryAborted reports whether err means the DB call was cut short rather
+// than failing. Client cancellations are only logged, since nobody is left
+// to read a response; query timeouts are counted and answered with 504.
+// Callers should return immediately when it reports true.
+func queryAborted(w http.ResponseWriter, r *http.Request, err error) bool {
+	log := logger.FromContext(r.Context())
+
+	switch {
+	case errors.Is(r.Context().Err(), context.Canceled):
+		log.Infow("Request cancelled by client",
+			"path", r.URL.Path,
+			"route", r.Pattern,
+		)
+		return true
+	case errors.Is(err, context.DeadlineExceeded):
+		metrics.QueryTimeoutsTotal.WithLabelValues(r.Pattern).Inc()
+		log.Warnw("Database query timed out",
+			"path", r.URL.Path,
+			"route", r.Pattern,
+		)
+		utils.RespondWithError(w, http.StatusGatewayTimeout, "Request timed out")
+		return true
+	}
+	return false
+}
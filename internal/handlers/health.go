@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"chirpy/internal/api"
+	"chirpy/internal/auth"
+	"chirpy/internal/health"
+	"chirpy/internal/logger"
+	"chirpy/internal/utils"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+)
+
+// HandleLivez reports that the process is up. It deliberately checks no
+// dependencies so a database outage never gets the pod restarted.
+func HandleLivez() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		utils.RespondWithJSON(w, http.StatusOK, map[string]string{"status": health.StatusOK})
+	}
+}
+
+// HandleReadyz runs the registered dependency checks and returns their
+// breakdown, with 503 if any fail. ?verbose=1 adds errors and timings for
+// operators sending cfg.HealthToken; it does not need the database, so it
+// works during the outages it is meant to diagnose.
+func HandleReadyz(cfg *api.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+
+		verbose, _ := strconv.ParseBool(r.URL.Query().Get("verbose"))
+		if verbose && !isOperator(cfg, r) {
+			log.Warnw("Verbose readiness refused", "remote_addr", r.RemoteAddr)
+			utils.RespondWithError(w, http.StatusForbidden, "Verbose readiness requires the health token")
+			return
+		}
+
+		report := cfg.Health.Run(r.Context())
+
+		status := http.StatusOK
+		if report.Status != health.StatusOK {
+			status = http.StatusServiceUnavailable
+			log.Warnw("Readiness check failed", "components", report.Components)
+		}
+
+		if !verbose {
+			report = report.Brief()
+		}
+		utils.RespondWithJSON(w, status, report)
+	}
+}
+
+// isOperator reports whether r carries the configured health token.
+func isOperator(cfg *api.Config, r *http.Request) bool {
+	return hasBearer(r, cfg.HealthToken)
+}
+
+// hasBearer reports whether r's Bearer token is want. An empty want
+// matches nothing.
+func hasBearer(r *http.Request, want string) bool {
+	if want == "" {
+		return false
+	}
+	token, err := auth.GetBearerToken(r.Header)
+	return err == nil && subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1
+}
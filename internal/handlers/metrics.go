@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"chirpy/internal/api"
+	"chirpy/internal/logger"
+	"chirpy/internal/metrics"
+	"chirpy/internal/utils"
+	"net/http"
+)
+
+// HandleMetrics serves the Prometheus metrics to scrapers sending
+// cfg.MetricsToken as a Bearer token. Like the readiness token it needs
+// no database, so metrics stay readable during an outage. Without a
+// token configured every request is refused.
+func HandleMetrics(cfg *api.Config) http.HandlerFunc {
+	h := metrics.Handler()
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !hasBearer(r, cfg.MetricsToken) {
+			logger.FromContext(r.Context()).Warnw("Metrics scrape refused", "remote_addr", r.RemoteAddr)
+			utils.RespondWithError(w, http.StatusForbidden, "Metrics require the metrics token")
+			return
+		}
+		h.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"chirpy/internal/api"
+	"chirpy/internal/database"
+	"chirpy/internal/logger"
+	"chirpy/internal/models"
+	"chirpy/internal/profanity"
+	"chirpy/internal/store"
+	"chirpy/internal/utils"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxModerationWordLength bounds a single wordlist entry.
+const maxModerationWordLength = 64
+
+func HandleListModerationWords(cfg *api.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		words, err := cfg.DB.ListModerationWords(ctx)
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to list moderation words", "error", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to list words")
+			return
+		}
+
+		resp := make([]models.ModerationWordResponse, 0, len(words))
+		for _, word := range words {
+			resp = append(resp, moderationWordResponse(word))
+		}
+		utils.RespondWithJSON(w, http.StatusOK, resp)
+	}
+}
+
+func HandleCreateModerationWord(cfg *api.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		// === 1. Decode and validate ===
+		word, severity, ok := decodeModerationWord(w, r)
+		if !ok {
+			return
+		}
+
+		// === 2. Insert ===
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		now := time.Now().UTC()
+		created, err := cfg.DB.CreateModerationWord(ctx, database.CreateModerationWordParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			Word:      word,
+			Severity:  string(severity),
+		})
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			if store.IsUniqueViolation(err) {
+				utils.RespondWithError(w, http.StatusConflict, "Word already exists")
+				return
+			}
+			log.Errorw("Failed to create moderation word",
+				"word", word,
+				"error", err,
+			)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create word")
+			return
+		}
+
+		// === 3. Apply locally; other instances pick it up when they poll ===
+		reloadWordlist(cfg, r)
+
+		log.Infow("Moderation word created",
+			"word_id", created.ID,
+			"word", created.Word,
+			"severity", created.Severity,
+		)
+		utils.RespondWithJSON(w, http.StatusCreated, moderationWordResponse(created))
+	}
+}
+
+func HandleUpdateModerationWord(cfg *api.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		// === 1. Parse ID and body ===
+		wordID, err := uuid.Parse(r.PathValue("wordID"))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid word ID")
+			return
+		}
+		word, severity, ok := decodeModerationWord(w, r)
+		if !ok {
+			return
+		}
+
+		// === 2. Update ===
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		updated, err := cfg.DB.UpdateModerationWord(ctx, database.UpdateModerationWordParams{
+			ID:        wordID,
+			Word:      word,
+			Severity:  string(severity),
+			UpdatedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			if errors.Is(err, sql.ErrNoRows) {
+				utils.RespondWithError(w, http.StatusNotFound, "Word not found")
+				return
+			}
+			if store.IsUniqueViolation(err) {
+				utils.RespondWithError(w, http.StatusConflict, "Word already exists")
+				return
+			}
+			log.Errorw("Failed to update moderation word",
+				"word_id", wordID,
+				"error", err,
+			)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update word")
+			return
+		}
+
+		// === 3. Apply locally ===
+		reloadWordlist(cfg, r)
+
+		log.Infow("Moderation word updated",
+			"word_id", updated.ID,
+			"word", updated.Word,
+			"severity", updated.Severity,
+		)
+		utils.RespondWithJSON(w, http.StatusOK, moderationWordResponse(updated))
+	}
+}
+
+func HandleDeleteModerationWord(cfg *api.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		wordID, err := uuid.Parse(r.PathValue("wordID"))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid word ID")
+			return
+		}
+
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		n, err := cfg.DB.DeleteModerationWord(ctx, wordID)
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to delete moderation word",
+				"word_id", wordID,
+				"error", err,
+			)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete word")
+			return
+		}
+		if n == 0 {
+			utils.RespondWithError(w, http.StatusNotFound, "Word not found")
+			return
+		}
+
+		reloadWordlist(cfg, r)
+
+		log.Infow("Moderation word deleted", "word_id", wordID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// decodeModerationWord reads a ModerationWordRequest, normalising the word
+// and defaulting the severity to mask. It answers the request itself when
+// the body is invalid.
+func decodeModerationWord(w http.ResponseWriter, r *http.Request) (string, profanity.Severity, bool) {
+	var req models.ModerationWordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return "", "", false
+	}
+
+	word := strings.ToLower(strings.TrimSpace(req.Word))
+	if !profanity.ValidWord(word) || len(word) > maxModerationWordLength {
+		utils.RespondWithError(w, http.StatusBadRequest, "word must be a single word of at most 64 bytes")
+		return "", "", false
+	}
+
+	severity := profanity.Severity(req.Severity)
+	switch severity {
+	case "":
+		severity = profanity.SeverityMask
+	case profanity.SeverityMask, profanity.SeverityBlock:
+	default:
+		utils.RespondWithError(w, http.StatusBadRequest, "severity must be mask or block")
+		return "", "", false
+	}
+	return word, severity, true
+}
+
+// reloadWordlist recompiles this instance's filter after an edit and
+// tells the other instances to do the same. Failure is only logged: the
+// edit is stored, and the next poll retries.
+func reloadWordlist(cfg *api.Config, r *http.Request) {
+	if cfg.Moderation == nil {
+		return
+	}
+	// Not bound to the request, which may already be finishing.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), api.DefaultQueryTimeout)
+	defer cancel()
+	if _, err := cfg.Moderation.Reload(ctx); err != nil {
+		logger.FromContext(r.Context()).Warnw("Failed to reload moderation wordlist", "error", err)
+	}
+	if err := cfg.Moderation.Announce(ctx); err != nil {
+		logger.FromContext(r.Context()).Warnw("Failed to announce moderation wordlist change", "error", err)
+	}
+}
+
+func moderationWordResponse(w database.ModerationWord) models.ModerationWordResponse {
+	return models.ModerationWordResponse{
+		ID:        w.ID,
+		CreatedAt: w.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: w.UpdatedAt.Format(time.RFC3339),
+		Word:      w.Word,
+		Severity:  w.Severity,
+	}
+}
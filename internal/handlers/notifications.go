@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"chirpy/internal/api"
+	"chirpy/internal/database"
+	"chirpy/internal/logger"
+	"chirpy/internal/models"
+	"chirpy/internal/notify"
+	"chirpy/internal/store"
+	"chirpy/internal/utils"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultNotificationPage = 20
+	maxNotificationPage     = 100
+	// maxMarkRead bounds the ids accepted by one mark-read request.
+	maxMarkRead = 100
+)
+
+// firstPage is a cursor position after every notification.
+var firstPage = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// HandleListNotifications lists the caller's notifications, newest
+// activity first. ?unread=true keeps only unread ones; ?limit and the
+// next_cursor of the previous response page through the rest.
+func HandleListNotifications(cfg *api.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		// === 1. Authenticate ===
+		userID, ok := authenticate(cfg, w, r)
+		if !ok {
+			return
+		}
+
+		// === 2. Parse query ===
+		query := r.URL.Query()
+		var unreadOnly bool
+		if s := query.Get("unread"); s != "" {
+			v, err := strconv.ParseBool(s)
+			if err != nil {
+				utils.RespondWithError(w, http.StatusBadRequest, "unread must be true or false")
+				return
+			}
+			unreadOnly = v
+		}
+		limit := defaultNotificationPage
+		if s := query.Get("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 || n > maxNotificationPage {
+				utils.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxNotificationPage))
+				return
+			}
+			limit = n
+		}
+		beforeAt, beforeID := firstPage, uuid.Nil
+		if s := query.Get("cursor"); s != "" {
+			var err error
+			if beforeAt, beforeID, err = decodeNotificationCursor(s); err != nil {
+				utils.RespondWithError(w, http.StatusBadRequest, "Invalid cursor")
+				return
+			}
+		}
+
+		// === 3. Fetch one extra row to learn whether there is a next page ===
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		arg := database.ListNotificationsParams{
+			UserID:   userID,
+			BeforeAt: beforeAt,
+			TieAt:    beforeAt,
+			BeforeID: beforeID,
+			PageSize: int32(limit + 1),
+		}
+		var rows []database.Notification
+		var err error
+		if unreadOnly {
+			rows, err = cfg.DB.ListUnreadNotifications(ctx, database.ListUnreadNotificationsParams(arg))
+		} else {
+			rows, err = cfg.DB.ListNotifications(ctx, arg)
+		}
+		var unread int64
+		if err == nil {
+			unread, err = cfg.DB.CountUnreadNotifications(ctx, userID)
+		}
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to list notifications", "user_id", userID, "error", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to list notifications")
+			return
+		}
+
+		// === 4. Build response ===
+		resp := models.NotificationListResponse{
+			Notifications: make([]models.NotificationResponse, 0, limit),
+			UnreadCount:   unread,
+		}
+		if len(rows) > limit {
+			rows = rows[:limit]
+			last := rows[limit-1]
+			resp.NextCursor = encodeNotificationCursor(last.UpdatedAt, last.ID)
+		}
+		for _, n := range rows {
+			resp.Notifications = append(resp.Notifications, notificationResponse(n))
+		}
+		utils.RespondWithJSON(w, http.StatusOK, resp)
+	}
+}
+
+// HandleMarkNotificationsRead marks the given notifications, or all of
+// the caller's, as read. Ids that are not the caller's or already read
+// are skipped.
+func HandleMarkNotificationsRead(cfg *api.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		// === 1. Authenticate ===
+		userID, ok := authenticate(cfg, w, r)
+		if !ok {
+			return
+		}
+
+		// === 2. Validate input ===
+		var req models.MarkNotificationsReadRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		if req.All == (len(req.IDs) > 0) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Provide either ids or all")
+			return
+		}
+		if len(req.IDs) > maxMarkRead {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("At most %d ids per request", maxMarkRead))
+			return
+		}
+
+		// === 3. Mark read ===
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		readAt := sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		var resp models.MarkNotificationsReadResponse
+		err := cfg.DB.InTx(ctx, func(tx store.Store) error {
+			if req.All {
+				n, err := tx.MarkAllNotificationsRead(ctx, database.MarkAllNotificationsReadParams{
+					UserID: userID, ReadAt: readAt,
+				})
+				resp.Marked = n
+				return err
+			}
+			for _, id := range req.IDs {
+				n, err := tx.MarkNotificationRead(ctx, database.MarkNotificationReadParams{
+					ID: id, UserID: userID, ReadAt: readAt,
+				})
+				if err != nil {
+					return err
+				}
+				resp.Marked += n
+			}
+			return nil
+		})
+		if err == nil {
+			resp.UnreadCount, err = cfg.DB.CountUnreadNotifications(ctx, userID)
+		}
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to mark notifications read", "user_id", userID, "error", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to mark notifications read")
+			return
+		}
+		utils.RespondWithJSON(w, http.StatusOK, resp)
+	}
+}
+
+func notificationResponse(n database.Notification) models.NotificationResponse {
+	resp := models.NotificationResponse{
+		ID:          n.ID,
+		CreatedAt:   n.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   n.UpdatedAt.Format(time.RFC3339),
+		Type:        n.Type,
+		ActorCount:  n.ActorCount,
+		LastActorID: n.LastActorID,
+		Read:        n.ReadAt.Valid,
+	}
+	if n.ChirpID.Valid {
+		resp.ChirpID = &n.ChirpID.UUID
+	}
+	return resp
+}
+
+// The cursor is the (updated_at, id) of the last notification on a page;
+// clients treat it as opaque.
+func encodeNotificationCursor(at time.Time, id uuid.UUID) string {
+	return strconv.FormatInt(at.UnixNano(), 10) + "_" + id.String()
+}
+
+func decodeNotificationCursor(s string) (time.Time, uuid.UUID, error) {
+	nanos, idStr, ok := strings.Cut(s, "_")
+	if !ok {
+		return time.Time{}, uuid.Nil, fmt.Errorf("malformed cursor %q", s)
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	return time.Unix(0, n).UTC(), id, nil
+}
+
+// eventNotification is the stream event carrying a new or grown
+// notification to its recipient's "notifications" channel.
+const eventNotification = "notification"
+
+// recordMentions notifies each user chirp mentions. Call it inside the
+// transaction that creates the chirp; it returns the notifications that
+// changed, to publish once that commits.
+func recordMentions(ctx context.Context, tx store.Store, chirp database.Chirp) ([]database.Notification, error) {
+	var changed []database.Notification
+	for _, email := range notify.Mentions(chirp.Body) {
+		user, err := tx.GetUserByEmail(ctx, email)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		n, ok, err := notify.Record(ctx, tx, notify.Event{
+			Type:    notify.TypeMention,
+			UserID:  user.ID,
+			ActorID: chirp.UserID,
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			changed = append(changed, n)
+		}
+	}
+	return changed, nil
+}
+
+// publishNotifications pushes committed notifications to their
+// recipients' sockets.
+func publishNotifications(cfg *api.Config, ns []database.Notification) {
+	for _, n := range ns {
+		cfg.Stream.PublishTo(n.UserID, eventNotification, n.LastActorID, notificationResponse(n))
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"chirpy/internal/api"
 	"chirpy/internal/logger"
+	"chirpy/internal/metrics"
 	"chirpy/internal/utils"
 	"chirpy/internal/models"
 	"chirpy/internal/auth"
@@ -14,30 +15,35 @@ import (
 
 func HandlePolkaWebhook(cfg *api.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger.Logger.Infow("Polka webhook received",
+		log := logger.FromContext(r.Context())
+		log.Infow("Polka webhook received",
 			"method", r.Method,
 			"path", r.URL.Path,
 		)
 		key, err := auth.GetAPIKey(r.Header)
 		if err != nil {
-			logger.Logger.Warnw("Missing or invalid API key", "error", err)
+			metrics.WebhooksTotal.WithLabelValues("polka", "unauthorized").Inc()
+			log.Warnw("Missing or invalid API key", "error", err)
 			utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 
 		if key != cfg.PolkaKey {
-			logger.Logger.Warnw("Invalid Polka API key", "provided_key", key)
+			metrics.WebhooksTotal.WithLabelValues("polka", "unauthorized").Inc()
+			log.Warnw("Invalid Polka API key", "provided_key", key)
 			utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
 			return
 		}
 		var payload models.PolkaPayload
 		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			metrics.WebhooksTotal.WithLabelValues("polka", "invalid").Inc()
 			utils.RespondWithError(w, http.StatusBadRequest, "Invalid JSON")
 			return
 		}
 
 		// 1. Ignore everything except user.upgraded
 		if payload.Event != "user.upgraded" {
+			metrics.WebhooksTotal.WithLabelValues("polka", "ignored").Inc()
 			utils.RespondWithJSON(w, http.StatusNoContent, nil)
 			return
 		}
@@ -45,18 +51,21 @@ func HandlePolkaWebhook(cfg *api.Config) http.HandlerFunc {
 		// 2. Parse UUID
 		userID, err := uuid.Parse(payload.Data.UserID)
 		if err != nil {
+			metrics.WebhooksTotal.WithLabelValues("polka", "invalid").Inc()
 			utils.RespondWithError(w, http.StatusBadRequest, "Invalid user_id")
 			return
 		}
 
 		// 3. Upgrade in DB
-		if err := cfg.DB.UpgradeToChirpyRed(r.Context(), userID); err != nil {
-			// sqlc returns sql.ErrNoRows when the UPDATE affects 0 rows
-			if err.Error() == "sql: no rows in result set" {
-				utils.RespondWithError(w, http.StatusNotFound, "User not found")
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		upgraded, err := cfg.DB.UpgradeToChirpyRed(ctx, userID)
+		if err != nil {
+			if queryAborted(w, r, err) {
 				return
 			}
-			logger.Logger.Errorw("Failed to upgrade user to Chirpy Red",
+			metrics.WebhooksTotal.WithLabelValues("polka", "error").Inc()
+			log.Errorw("Failed to upgrade user to Chirpy Red",
 				"error", err,
 				"user_id", userID,
 			)
@@ -64,7 +73,14 @@ func HandlePolkaWebhook(cfg *api.Config) http.HandlerFunc {
 			return
 		}
 
+		if upgraded == 0 {
+			metrics.WebhooksTotal.WithLabelValues("polka", "not_found").Inc()
+			utils.RespondWithError(w, http.StatusNotFound, "User not found")
+			return
+		}
+
 		// 4. Success → 204
+		metrics.WebhooksTotal.WithLabelValues("polka", "upgraded").Inc()
 		utils.RespondWithJSON(w, http.StatusNoContent, nil)
 	}
 }
\ No newline at end of file
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"chirpy/internal/api"
+	"chirpy/internal/database"
+	"chirpy/internal/logger"
+	"chirpy/internal/models"
+	"chirpy/internal/store"
+	"chirpy/internal/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// relationKind describes one of the caller's user lists under
+// /api/users/me: blocks or mutes. The handlers are shared; only the
+// queries and wording differ.
+type relationKind struct {
+	noun   string // "block" or "mute"
+	past   string // "blocked" or "muted"
+	create func(ctx context.Context, db store.Store, owner, target uuid.UUID, at time.Time) (time.Time, error)
+	remove func(ctx context.Context, db store.Store, owner, target uuid.UUID) (int64, error)
+	list   func(ctx context.Context, db store.Store, owner uuid.UUID) ([]models.RelationshipResponse, error)
+}
+
+var blockRelation = relationKind{
+	noun: "block",
+	past: "blocked",
+	create: func(ctx context.Context, db store.Store, owner, target uuid.UUID, at time.Time) (time.Time, error) {
+		b, err := db.CreateBlock(ctx, database.CreateBlockParams{BlockerID: owner, BlockedID: target, CreatedAt: at})
+		return b.CreatedAt, err
+	},
+	remove: func(ctx context.Context, db store.Store, owner, target uuid.UUID) (int64, error) {
+		return db.DeleteBlock(ctx, database.DeleteBlockParams{BlockerID: owner, BlockedID: target})
+	},
+	list: func(ctx context.Context, db store.Store, owner uuid.UUID) ([]models.RelationshipResponse, error) {
+		blocks, err := db.ListBlocks(ctx, owner)
+		resp := make([]models.RelationshipResponse, len(blocks))
+		for i, b := range blocks {
+			resp[i] = relationshipResponse(b.BlockedID, b.CreatedAt)
+		}
+		return resp, err
+	},
+}
+
+var muteRelation = relationKind{
+	noun: "mute",
+	past: "muted",
+	create: func(ctx context.Context, db store.Store, owner, target uuid.UUID, at time.Time) (time.Time, error) {
+		m, err := db.CreateMute(ctx, database.CreateMuteParams{MuterID: owner, MutedID: target, CreatedAt: at})
+		return m.CreatedAt, err
+	},
+	remove: func(ctx context.Context, db store.Store, owner, target uuid.UUID) (int64, error) {
+		return db.DeleteMute(ctx, database.DeleteMuteParams{MuterID: owner, MutedID: target})
+	},
+	list: func(ctx context.Context, db store.Store, owner uuid.UUID) ([]models.RelationshipResponse, error) {
+		mutes, err := db.ListMutes(ctx, owner)
+		resp := make([]models.RelationshipResponse, len(mutes))
+		for i, m := range mutes {
+			resp[i] = relationshipResponse(m.MutedID, m.CreatedAt)
+		}
+		return resp, err
+	},
+}
+
+// HandleListBlocks lists the users the caller has blocked.
+func HandleListBlocks(cfg *api.Config) http.HandlerFunc {
+	return handleListRelations(cfg, blockRelation)
+}
+
+// HandleCreateBlock blocks a user: neither side sees the other's chirps.
+func HandleCreateBlock(cfg *api.Config) http.HandlerFunc {
+	return handleCreateRelation(cfg, blockRelation)
+}
+
+// HandleDeleteBlock unblocks a user.
+func HandleDeleteBlock(cfg *api.Config) http.HandlerFunc {
+	return handleDeleteRelation(cfg, blockRelation)
+}
+
+// HandleListMutes lists the users the caller has muted.
+func HandleListMutes(cfg *api.Config) http.HandlerFunc {
+	return handleListRelations(cfg, muteRelation)
+}
+
+// HandleCreateMute mutes a user: their chirps are hidden from the caller
+// only, and they are not told.
+func HandleCreateMute(cfg *api.Config) http.HandlerFunc {
+	return handleCreateRelation(cfg, muteRelation)
+}
+
+// HandleDeleteMute unmutes a user.
+func HandleDeleteMute(cfg *api.Config) http.HandlerFunc {
+	return handleDeleteRelation(cfg, muteRelation)
+}
+
+func handleListRelations(cfg *api.Config, kind relationKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		userID, ok := authenticate(cfg, w, r)
+		if !ok {
+			return
+		}
+
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		resp, err := kind.list(ctx, cfg.DB, userID)
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to list "+kind.noun+"s", "user_id", userID, "error", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to list "+kind.noun+"s")
+			return
+		}
+		utils.RespondWithJSON(w, http.StatusOK, resp)
+	}
+}
+
+func handleCreateRelation(cfg *api.Config, kind relationKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		// === 1. Authenticate ===
+		userID, ok := authenticate(cfg, w, r)
+		if !ok {
+			return
+		}
+
+		// === 2. Validate input ===
+		var req models.RelationshipRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == uuid.Nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "user_id is required")
+			return
+		}
+		if req.UserID == userID {
+			utils.RespondWithError(w, http.StatusBadRequest, "You cannot "+kind.noun+" yourself")
+			return
+		}
+
+		// === 3. Store ===
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		createdAt, err := kind.create(ctx, cfg.DB, userID, req.UserID, time.Now().UTC())
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			if store.IsUniqueViolation(err) {
+				utils.RespondWithError(w, http.StatusConflict, "User already "+kind.past)
+				return
+			}
+			if store.IsForeignKeyViolation(err) {
+				utils.RespondWithError(w, http.StatusNotFound, "User not found")
+				return
+			}
+			log.Errorw("Failed to "+kind.noun+" user",
+				"user_id", userID,
+				"target_id", req.UserID,
+				"error", err,
+			)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to "+kind.noun+" user")
+			return
+		}
+
+		log.Infow("User "+kind.past, "user_id", userID, "target_id", req.UserID)
+		utils.RespondWithJSON(w, http.StatusCreated, relationshipResponse(req.UserID, createdAt))
+	}
+}
+
+func handleDeleteRelation(cfg *api.Config, kind relationKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		userID, ok := authenticate(cfg, w, r)
+		if !ok {
+			return
+		}
+		targetID, err := uuid.Parse(r.PathValue("userID"))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+			return
+		}
+
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		n, err := kind.remove(ctx, cfg.DB, userID, targetID)
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to un"+kind.noun+" user",
+				"user_id", userID,
+				"target_id", targetID,
+				"error", err,
+			)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to un"+kind.noun+" user")
+			return
+		}
+		if n == 0 {
+			utils.RespondWithError(w, http.StatusNotFound, "User is not "+kind.past)
+			return
+		}
+
+		log.Infow("User un"+kind.past, "user_id", userID, "target_id", targetID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func relationshipResponse(userID uuid.UUID, createdAt time.Time) models.RelationshipResponse {
+	return models.RelationshipResponse{
+		UserID:    userID,
+		CreatedAt: createdAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"chirpy/internal/api"
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	"chirpy/internal/logger"
+	"chirpy/internal/models"
+	"chirpy/internal/outbox"
+	"chirpy/internal/store"
+	"chirpy/internal/utils"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// reportReasons are the reason codes accepted by HandleCreateReport.
+var reportReasons = map[string]bool{
+	"spam":           true,
+	"harassment":     true,
+	"hate":           true,
+	"violence":       true,
+	"sexual":         true,
+	"self_harm":      true,
+	"misinformation": true,
+	"other":          true,
+}
+
+// Report statuses and resolution actions.
+const (
+	reportOpen     = "open"
+	reportResolved = "resolved"
+
+	actionHide    = "hide"
+	actionDelete  = "delete"
+	actionSuspend = "suspend"
+	actionDismiss = "dismiss"
+)
+
+// defaultSuspension applies when a suspend resolution has no suspend_for.
+const defaultSuspension = 7 * 24 * time.Hour
+
+func HandleCreateReport(cfg *api.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		// === 1. Authenticate reporter ===
+		tokenStr, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusUnauthorized, "Missing or invalid Authorization header")
+			return
+		}
+		reporterID, err := auth.ValidateJWT(tokenStr, cfg.JWTSecret)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+
+		// === 2. Validate input ===
+		chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid chirp ID")
+			return
+		}
+		var req models.ReportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		if !reportReasons[req.Reason] {
+			utils.RespondWithError(w, http.StatusBadRequest, "Unknown report reason")
+			return
+		}
+
+		// === 3. Check the chirp can be reported ===
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		chirp, err := cfg.DB.GetChirpByID(ctx, chirpID)
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			if errors.Is(err, sql.ErrNoRows) {
+				utils.RespondWithError(w, http.StatusNotFound, "Chirp not found")
+				return
+			}
+			log.Errorw("Failed to fetch reported chirp",
+				"chirp_id", chirpID,
+				"error", err,
+			)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to report chirp")
+			return
+		}
+		if chirp.UserID == reporterID {
+			utils.RespondWithError(w, http.StatusBadRequest, "You cannot report your own chirp")
+			return
+		}
+		if chirp.HiddenAt.Valid {
+			utils.RespondWithError(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+
+		// === 4. Store the report ===
+		now := time.Now().UTC()
+		report, err := cfg.DB.CreateReport(ctx, database.CreateReportParams{
+			ID:         uuid.New(),
+			CreatedAt:  now,
+			UpdatedAt:  now,
+			ChirpID:    chirpID,
+			ReporterID: reporterID,
+			Reason:     req.Reason,
+		})
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			if store.IsUniqueViolation(err) {
+				utils.RespondWithError(w, http.StatusConflict, "You have already reported this chirp")
+				return
+			}
+			if store.IsForeignKeyViolation(err) {
+				// The chirp was deleted, or the reporter's account was.
+				utils.RespondWithError(w, http.StatusNotFound, "Chirp not found")
+				return
+			}
+			log.Errorw("Failed to create report",
+				"chirp_id", chirpID,
+				"reporter_id", reporterID,
+				"error", err,
+			)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to report chirp")
+			return
+		}
+
+		log.Infow("Chirp reported",
+			"report_id", report.ID,
+			"chirp_id", chirpID,
+			"reporter_id", reporterID,
+			"reason", report.Reason,
+		)
+		utils.RespondWithJSON(w, http.StatusCreated, reportResponse(report))
+	}
+}
+
+func HandleListReports(cfg *api.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		status := r.URL.Query().Get("status")
+		switch status {
+		case "":
+			status = reportOpen
+		case reportOpen, reportResolved:
+		default:
+			utils.RespondWithError(w, http.StatusBadRequest, "status must be open or resolved")
+			return
+		}
+
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		rows, err := cfg.DB.ListReports(ctx, status)
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to list reports", "error", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to list reports")
+			return
+		}
+
+		resp := make([]models.ReportResponse, 0, len(rows))
+		for _, row := range rows {
+			item := reportResponse(database.Report{
+				ID:         row.ID,
+				CreatedAt:  row.CreatedAt,
+				UpdatedAt:  row.UpdatedAt,
+				ChirpID:    row.ChirpID,
+				ReporterID: row.ReporterID,
+				Reason:     row.Reason,
+				Status:     row.Status,
+				Action:     row.Action,
+				ResolvedAt: row.ResolvedAt,
+			})
+			chirp := chirpResponse(database.Chirp{
+				ID:        row.ChirpID,
+				CreatedAt: row.ChirpCreatedAt,
+				UpdatedAt: row.ChirpUpdatedAt,
+				Body:      row.ChirpBody,
+				UserID:    row.ChirpUserID,
+				HiddenAt:  row.ChirpHiddenAt,
+			})
+			item.Chirp = &chirp
+			resp = append(resp, item)
+		}
+		utils.RespondWithJSON(w, http.StatusOK, resp)
+	}
+}
+
+func HandleResolveReport(cfg *api.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		// === 1. Validate input ===
+		reportID, err := uuid.Parse(r.PathValue("reportID"))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid report ID")
+			return
+		}
+		var req models.ResolveReportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		suspendFor := defaultSuspension
+		switch req.Action {
+		case actionHide, actionDelete, actionDismiss:
+		case actionSuspend:
+			if req.SuspendFor != "" {
+				suspendFor, err = time.ParseDuration(req.SuspendFor)
+				if err != nil || suspendFor <= 0 {
+					utils.RespondWithError(w, http.StatusBadRequest, "suspend_for must be a positive duration such as 72h")
+					return
+				}
+			}
+		default:
+			utils.RespondWithError(w, http.StatusBadRequest, "action must be hide, delete, suspend or dismiss")
+			return
+		}
+
+		// === 2. Load the report and its chirp ===
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		report, err := cfg.DB.GetReport(ctx, reportID)
+		if err == nil && report.Status != reportOpen {
+			utils.RespondWithError(w, http.StatusConflict, "Report already resolved")
+			return
+		}
+		var chirp database.Chirp
+		if err == nil {
+			chirp, err = cfg.DB.GetChirpByID(ctx, report.ChirpID)
+		}
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			if errors.Is(err, sql.ErrNoRows) {
+				utils.RespondWithError(w, http.StatusNotFound, "Report not found")
+				return
+			}
+			log.Errorw("Failed to load report",
+				"report_id", reportID,
+				"error", err,
+			)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to resolve report")
+			return
+		}
+
+		// === 3. Apply the action and resolve atomically ===
+		// Acting on the chirp settles every open report about it; dismissing
+		// settles only this one.
+		now := time.Now().UTC()
+		resolved := database.ResolveOpenReportsForChirpParams{
+			ChirpID:    chirp.ID,
+			Action:     sql.NullString{String: req.Action, Valid: true},
+			ResolvedAt: sql.NullTime{Time: now, Valid: true},
+			UpdatedAt:  now,
+		}
+		err = cfg.DB.InTx(ctx, func(tx store.Store) error {
+			// Claim the report before acting: if another moderator resolved
+			// it since we loaded it, this finds no open report and the
+			// transaction rolls back without applying the action twice.
+			var err error
+			report, err = tx.ResolveReport(ctx, database.ResolveReportParams{
+				ID:         reportID,
+				Action:     resolved.Action,
+				ResolvedAt: resolved.ResolvedAt,
+				UpdatedAt:  now,
+			})
+			if err != nil {
+				return err
+			}
+
+			switch req.Action {
+			case actionDismiss:
+				return nil
+
+			case actionHide:
+				if _, err := tx.HideChirp(ctx, database.HideChirpParams{
+					ID:        chirp.ID,
+					HiddenAt:  sql.NullTime{Time: now, Valid: true},
+					UpdatedAt: now,
+				}); err != nil {
+					return err
+				}
+				if _, err := tx.ResolveOpenReportsForChirp(ctx, resolved); err != nil {
+					return err
+				}
+				return outbox.Enqueue(ctx, tx, outbox.EventChirpHidden, chirp.ID, outbox.ChirpPayload{
+					ChirpID:   chirp.ID,
+					UserID:    chirp.UserID,
+					CreatedAt: chirp.CreatedAt,
+				})
+
+			case actionDelete:
+				// The chirp's reports are deleted with it.
+				if err := tx.DeleteChirp(ctx, chirp.ID); err != nil {
+					return err
+				}
+				return outbox.Enqueue(ctx, tx, outbox.EventChirpDeleted, chirp.ID, outbox.ChirpPayload{
+					ChirpID:   chirp.ID,
+					UserID:    chirp.UserID,
+					CreatedAt: chirp.CreatedAt,
+				})
+
+			default: // actionSuspend
+				if _, err := tx.SuspendUser(ctx, database.SuspendUserParams{
+					ID:             chirp.UserID,
+					SuspendedUntil: sql.NullTime{Time: now.Add(suspendFor), Valid: true},
+					UpdatedAt:      now,
+				}); err != nil {
+					return err
+				}
+				if _, err := tx.RevokeAllRefreshTokensForUser(ctx, database.RevokeAllRefreshTokensForUserParams{
+					UserID:    chirp.UserID,
+					RevokedAt: sql.NullTime{Time: now, Valid: true},
+					UpdatedAt: now,
+				}); err != nil {
+					return err
+				}
+				_, err := tx.ResolveOpenReportsForChirp(ctx, resolved)
+				return err
+			}
+		})
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			if errors.Is(err, sql.ErrNoRows) {
+				// Resolved by someone else since we loaded it.
+				utils.RespondWithError(w, http.StatusConflict, "Report already resolved")
+				return
+			}
+			log.Errorw("Failed to resolve report",
+				"report_id", reportID,
+				"action", req.Action,
+				"error", err,
+			)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to resolve report")
+			return
+		}
+
+		switch req.Action {
+		case actionHide:
+			publishChirp(cfg, outbox.EventChirpHidden, chirp)
+		case actionDelete:
+			publishChirp(cfg, outbox.EventChirpDeleted, chirp)
+		}
+		log.Infow("Report resolved",
+			"report_id", reportID,
+			"chirp_id", chirp.ID,
+			"author_id", chirp.UserID,
+			"action", req.Action,
+		)
+		utils.RespondWithJSON(w, http.StatusOK, reportResponse(report))
+	}
+}
+
+func reportResponse(r database.Report) models.ReportResponse {
+	resp := models.ReportResponse{
+		ID:         r.ID,
+		CreatedAt:  r.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  r.UpdatedAt.Format(time.RFC3339),
+		ChirpID:    r.ChirpID,
+		ReporterID: r.ReporterID,
+		Reason:     r.Reason,
+		Status:     r.Status,
+		Action:     r.Action.String,
+	}
+	if r.ResolvedAt.Valid {
+		resp.ResolvedAt = r.ResolvedAt.Time.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"chirpy/internal/api"
+	"chirpy/internal/database"
+	"chirpy/internal/logger"
+	"chirpy/internal/models"
+	"chirpy/internal/outbox"
+	"chirpy/internal/stream"
+	"chirpy/internal/utils"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// streamRetry is the reconnect delay suggested to EventSource clients.
+const streamRetry = 3 * time.Second
+
+// eventStreamReset tells a resuming client that events were missed and it
+// should reload with GET /api/chirps.
+const eventStreamReset = "stream.reset"
+
+// HandleChirpStream pushes chirp.created, chirp.deleted and chirp.hidden
+// events as Server-Sent Events, optionally only for ?author_id=. Clients
+// resume with the Last-Event-ID header (or ?last_event_id=) from the hub's
+// replay buffer.
+func HandleChirpStream(cfg *api.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		if cfg.Stream == nil {
+			utils.RespondWithError(w, http.StatusServiceUnavailable, "Chirp stream unavailable")
+			return
+		}
+
+		// === 1. Validate input ===
+		var authorID uuid.UUID
+		if s := r.URL.Query().Get("author_id"); s != "" {
+			id, err := uuid.Parse(s)
+			if err != nil {
+				utils.RespondWithError(w, http.StatusBadRequest, "Invalid author_id")
+				return
+			}
+			authorID = id
+		}
+		// An ID this hub did not issue, such as one from another replica,
+		// resets the client instead of failing, since EventSource would
+		// resend it on every retry.
+		lastID := r.Header.Get("Last-Event-ID")
+		if lastID == "" {
+			lastID = r.URL.Query().Get("last_event_id")
+		}
+
+		// === 2. Apply the viewer's blocks and mutes, as of connecting ===
+		viewer := viewerID(cfg, r)
+		ctx, cancel := cfg.QueryContext(r)
+		excluded, err := hiddenAuthors(ctx, cfg, viewer)
+		cancel()
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to load blocks and mutes", "user_id", viewer, "error", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to open stream")
+			return
+		}
+		filter := func(evt stream.Event) bool {
+			if evt.Recipient != uuid.Nil {
+				return false
+			}
+			if authorID != uuid.Nil && evt.AuthorID != authorID {
+				return false
+			}
+			return excluded[evt.AuthorID] == ""
+		}
+
+		// === 3. Subscribe and replay ===
+		sub, replay, complete := cfg.Stream.Subscribe(lastID, filter)
+		defer cfg.Stream.Unsubscribe(sub)
+
+		// The server's write timeout would cut the stream off.
+		rc := http.NewResponseController(w)
+		_ = rc.SetWriteDeadline(time.Time{})
+
+		h := w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+		if !complete {
+			fmt.Fprintf(w, "event: %s\ndata: {}\n\n", eventStreamReset)
+		}
+		for _, evt := range replay {
+			writeStreamEvent(w, evt)
+		}
+		if err := rc.Flush(); err != nil {
+			log.Warnw("Chirp stream not flushable", "error", err)
+			return
+		}
+		log.Infow("Chirp stream opened",
+			"user_id", viewer,
+			"author_id", authorID,
+			"last_event_id", lastID,
+			"replayed", len(replay),
+		)
+
+		// === 4. Forward events, with heartbeats while idle ===
+		heartbeat := time.NewTicker(cfg.Stream.Heartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-sub.Done():
+				log.Infow("Chirp stream closed by server", "reason", sub.Err())
+				return
+			case evt := <-sub.Events():
+				writeStreamEvent(w, evt)
+			case <-heartbeat.C:
+				_, _ = io.WriteString(w, ": ping\n\n")
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeStreamEvent writes evt in the text/event-stream format. Data is
+// single-line JSON, so one data field suffices.
+func writeStreamEvent(w io.Writer, evt stream.Event) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", evt.StreamID(), evt.Type, evt.Data)
+}
+
+// publishChirp tells live clients about a committed chirp change.
+func publishChirp(cfg *api.Config, eventType string, c database.Chirp) {
+	var data any = models.ChirpRemovedEvent{ID: c.ID, UserID: c.UserID}
+	if eventType == outbox.EventChirpCreated {
+		data = chirpResponse(c)
+	}
+	cfg.Stream.Publish(eventType, c.UserID, data)
+}
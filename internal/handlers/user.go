@@ -5,18 +5,21 @@ import (
 	"chirpy/internal/auth"
 	"chirpy/internal/database"
 	"chirpy/internal/logger"
+	"chirpy/internal/metrics"
 	"chirpy/internal/models"
+	"chirpy/internal/store"
 	"chirpy/internal/utils"
-	"context"
 	"encoding/json"
 	"net/http"
-	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 func HandleCreateUser(cfg *api.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger.Logger.Infow("Create user request",
+		log := logger.FromContext(r.Context())
+		log.Infow("Create user request",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"remote_addr", r.RemoteAddr,
@@ -29,7 +32,7 @@ func HandleCreateUser(cfg *api.Config) http.HandlerFunc {
 
 		var req models.CreateUserRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			logger.Logger.Warnw("Invalid JSON for user creation",
+			log.Warnw("Invalid JSON for user creation",
 				"error", err,
 			)
 			utils.RespondWithError(w, http.StatusBadRequest, "Invalid JSON")
@@ -37,40 +40,49 @@ func HandleCreateUser(cfg *api.Config) http.HandlerFunc {
 		}
 
 		if req.Email == "" {
-			logger.Logger.Warnw("Empty email supplied")
+			log.Warnw("Empty email supplied")
 			utils.RespondWithError(w, http.StatusBadRequest, "Email is required")
 			return
 		}
 
 		if req.Password == "" {
-			logger.Logger.Warnw("Empty Password Supplied")
+			log.Warnw("Empty Password Supplied")
 			utils.RespondWithError(w, http.StatusBadRequest, "Password is required")
+			return
 		}
 
-		hash, err := auth.HashPassword(req.Password)
+		hash, err := auth.HashPassword(r.Context(), req.Password)
 
 		if err != nil {
-			logger.Logger.Errorw("Failed to hash password", "error", err)
+			log.Errorw("Failed to hash password", "error", err)
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to process password")
 			return
 		}
 
 
 
-		ctx := context.Background()
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		now := time.Now().UTC()
 		user, err := cfg.DB.CreateUser(ctx, database.CreateUserParams{
-			Email: req.Email,
+			ID:             uuid.New(),
+			CreatedAt:      now,
+			UpdatedAt:      now,
+			Email:          req.Email,
 			HashedPassword: hash,
 		})
 		if err != nil {
-			if strings.Contains(err.Error(), "duplicate key") {
-				logger.Logger.Warnw("Duplicate email attempt",
+			if queryAborted(w, r, err) {
+				return
+			}
+			if store.IsUniqueViolation(err) {
+				log.Warnw("Duplicate email attempt",
 					"email", req.Email,
 				)
 				utils.RespondWithError(w, http.StatusConflict, "Email already exists")
 				return
 			}
-			logger.Logger.Errorw("Failed to insert user into DB",
+			log.Errorw("Failed to insert user into DB",
 				"email", req.Email,
 				"error", err,
 			)
@@ -78,6 +90,8 @@ func HandleCreateUser(cfg *api.Config) http.HandlerFunc {
 			return
 		}
 
+		metrics.UsersCreatedTotal.Inc()
+
 		resp := models.CreateUserResponse{
 			ID:        user.ID,
 			Email:     user.Email,
@@ -86,7 +100,7 @@ func HandleCreateUser(cfg *api.Config) http.HandlerFunc {
 			IsChirpyRed: user.IsChirpyRed,
 		}
 
-		logger.Logger.Infow("User created successfully",
+		log.Infow("User created successfully",
 			"user_id", user.ID,
 			"email", user.Email,
 		)
@@ -97,10 +111,11 @@ func HandleCreateUser(cfg *api.Config) http.HandlerFunc {
 
 func HandleUpdateUser(cfg *api.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
 		// === 1. Extract and validate JWT ===
 		tokenStr, err := auth.GetBearerToken(r.Header)
 		if err != nil {
-			logger.Logger.Warnw("Missing or malformed Authorization header",
+			log.Warnw("Missing or malformed Authorization header",
 				"error", err,
 				"path", r.URL.Path,
 			)
@@ -110,7 +125,7 @@ func HandleUpdateUser(cfg *api.Config) http.HandlerFunc {
 
 		userID, err := auth.ValidateJWT(tokenStr, cfg.JWTSecret)
 		if err != nil {
-			logger.Logger.Infow("Invalid or expired access token",
+			log.Infow("Invalid or expired access token",
 				"error", err,
 				"token_preview", auth.TruncateToken(tokenStr),
 			)
@@ -121,7 +136,7 @@ func HandleUpdateUser(cfg *api.Config) http.HandlerFunc {
 		// === 2. Parse request body ===
 		var req models.UpdateUserRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			logger.Logger.Warnw("Invalid JSON payload for user update",
+			log.Warnw("Invalid JSON payload for user update",
 				"error", err,
 			)
 			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -140,28 +155,42 @@ func HandleUpdateUser(cfg *api.Config) http.HandlerFunc {
 
 
 		// === 4. Hash new password ===
-		hashedPassword, err := auth.HashPassword(req.Password)
+		hashedPassword, err := auth.HashPassword(r.Context(), req.Password)
 		if err != nil {
-			logger.Logger.Errorw("Failed to hash password", "error", err)
+			log.Errorw("Failed to hash password", "error", err)
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update user")
 			return
 		}
 
 		// === 5. Update user in DB ===
-		ctx := context.Background()
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
 		updatedUser, err := cfg.DB.UpdateUser(ctx, database.UpdateUserParams{
 			ID:             userID,
 			Email:          req.Email,
 			HashedPassword: hashedPassword,
+			UpdatedAt:      time.Now().UTC(),
 		})
 		if err != nil {
-			logger.Logger.Errorw("Failed to update user in database",
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to update user in database",
 				"error", err,
 				"user_id", userID,
 			)
 			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update user")
 			return
 		}
+		unread, err := cfg.DB.CountUnreadNotifications(ctx, userID)
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to count unread notifications", "user_id", userID, "error", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update user")
+			return
+		}
 		// === 6. Build response (omit password) ===
 		resp := models.UpdateUserResponse{
 			ID:        updatedUser.ID,
@@ -169,10 +198,11 @@ func HandleUpdateUser(cfg *api.Config) http.HandlerFunc {
 			CreatedAt: updatedUser.CreatedAt.Format(time.RFC3339),
 			UpdatedAt: updatedUser.UpdatedAt.Format(time.RFC3339),
 			IsChirpyRed: updatedUser.IsChirpyRed,
+			UnreadNotifications: unread,
 		}
 
 		// === 7. Log success ===
-		logger.Logger.Infow("User updated successfully",
+		log.Infow("User updated successfully",
 			"user_id", updatedUser.ID,
 			"new_email", updatedUser.Email,
 		)
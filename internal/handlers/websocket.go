@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"bufio"
+	"chirpy/internal/api"
+	"chirpy/internal/auth"
+	"chirpy/internal/logger"
+	"chirpy/internal/models"
+	"chirpy/internal/stream"
+	"chirpy/internal/utils"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// socketWriteWait bounds each frame written to a WebSocket client.
+	socketWriteWait = 10 * time.Second
+	// socketMaxMessage caps frames sent by clients; they only send small
+	// subscribe requests.
+	socketMaxMessage = 4096
+	// socketReplyBuffer is how many replies to client requests may queue
+	// before the client is disconnected for not reading them.
+	socketReplyBuffer = 16
+)
+
+// WebSocket channels. A user's chirps are "chirps:<user_id>".
+const (
+	channelChirps        = "chirps"
+	channelUserPrefix    = "chirps:"
+	channelNotifications = "notifications"
+)
+
+// socketTokenProtocol is the subprotocol browsers offer ahead of their
+// access token, since they cannot set headers on a WebSocket: they open
+// it with protocols ["access_token", "<jwt>"] and the server picks
+// "access_token".
+const socketTokenProtocol = "access_token"
+
+// socketOrigin reports whether a browser on the request's Origin may open
+// a socket: its own site, or one of cfg.AllowedOrigins. Requests without
+// an Origin header do not come from a browser and are allowed.
+func socketOrigin(cfg *api.Config) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if slices.Contains(cfg.AllowedOrigins, "*") || slices.Contains(cfg.AllowedOrigins, origin) {
+			return true
+		}
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+}
+
+// socketToken returns the access token from the Sec-WebSocket-Protocol
+// header or, failing that, the Authorization header.
+func socketToken(r *http.Request) (string, error) {
+	protocols := websocket.Subprotocols(r)
+	for i, p := range protocols {
+		if p == socketTokenProtocol && i+1 < len(protocols) {
+			return protocols[i+1], nil
+		}
+	}
+	return auth.GetBearerToken(r.Header)
+}
+
+// HandleChirpSocket upgrades to a WebSocket that carries the same events
+// as the chirp stream. Clients subscribe to "chirps" (every chirp),
+// "chirps:<user_id>" (one author's) or "notifications" (events addressed
+// to them) and get each matching event once, in an "event" frame, even
+// when several of their channels match. The token comes from the
+// Authorization header or, for browsers, the Sec-WebSocket-Protocol
+// header; browsers must also be on an allowed origin.
+func HandleChirpSocket(cfg *api.Config) http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		CheckOrigin:  socketOrigin(cfg),
+		Subprotocols: []string{socketTokenProtocol},
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		if cfg.Stream == nil {
+			utils.RespondWithError(w, http.StatusServiceUnavailable, "Chirp stream unavailable")
+			return
+		}
+
+		// === 1. Authenticate before upgrading ===
+		if !upgrader.CheckOrigin(r) {
+			utils.RespondWithError(w, http.StatusForbidden, "Origin not allowed")
+			return
+		}
+		tokenStr, err := socketToken(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusUnauthorized, "Missing or invalid Authorization header")
+			return
+		}
+		userID, err := auth.ValidateJWT(tokenStr, cfg.JWTSecret)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+
+		// === 2. Apply the user's blocks and mutes, as of connecting ===
+		ctx, cancel := cfg.QueryContext(r)
+		excluded, err := hiddenAuthors(ctx, cfg, userID)
+		cancel()
+		if err != nil {
+			if queryAborted(w, r, err) {
+				return
+			}
+			log.Errorw("Failed to load blocks and mutes", "user_id", userID, "error", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to open socket")
+			return
+		}
+
+		// === 3. Upgrade ===
+		conn, err := upgrader.Upgrade(hijackable{w}, r, nil)
+		if err != nil {
+			// The upgrader has already answered with an error status.
+			log.Infow("WebSocket upgrade failed", "user_id", userID, "error", err)
+			return
+		}
+		defer conn.Close()
+
+		s := &socket{user: userID, excluded: excluded, channels: make(map[string]bool)}
+		sub, _, _ := cfg.Stream.Subscribe("", s.accepts)
+		defer cfg.Stream.Unsubscribe(sub)
+		log.Infow("Chirp socket opened", "user_id", userID)
+
+		// === 4. Read client requests; pongs extend the read deadline ===
+		pongWait := 2 * cfg.Stream.Heartbeat
+		conn.SetReadLimit(socketMaxMessage)
+		_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(pongWait))
+		})
+		replies := make(chan models.SocketMessage, socketReplyBuffer)
+		readDone := make(chan error, 1)
+		go func() {
+			readDone <- s.read(conn, replies)
+		}()
+
+		// === 5. Write events, replies and pings; this is the only writer ===
+		ping := time.NewTicker(cfg.Stream.Heartbeat)
+		defer ping.Stop()
+		write := func(msg models.SocketMessage) error {
+			_ = conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+			return conn.WriteJSON(msg)
+		}
+		for {
+			var err error
+			select {
+			case err = <-readDone:
+				log.Infow("Chirp socket closed", "user_id", userID, "reason", err)
+				return
+			case <-sub.Done():
+				code := websocket.CloseGoingAway
+				if errors.Is(sub.Err(), stream.ErrSlowConsumer) {
+					code = websocket.CloseTryAgainLater
+				}
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(code, sub.Err().Error()),
+					time.Now().Add(socketWriteWait))
+				log.Infow("Chirp socket closed by server", "user_id", userID, "reason", sub.Err())
+				return
+			case evt := <-sub.Events():
+				// The client may have unsubscribed since the hub queued it.
+				if channel, ok := s.channelFor(evt); ok {
+					err = write(models.SocketMessage{
+						Type:    "event",
+						Channel: channel,
+						Event:   evt.Type,
+						ID:      evt.StreamID(),
+						Data:    evt.Data,
+					})
+				}
+			case msg := <-replies:
+				err = write(msg)
+			case <-ping.C:
+				err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(socketWriteWait))
+			}
+			if err != nil {
+				log.Infow("Chirp socket write failed", "user_id", userID, "error", err)
+				return
+			}
+		}
+	}
+}
+
+// socket is one WebSocket connection's subscriptions. The hub calls
+// accepts while publishing, so the channel set has its own lock.
+type socket struct {
+	user     uuid.UUID
+	excluded map[uuid.UUID]string
+
+	mu       sync.Mutex
+	channels map[string]bool
+}
+
+// accepts is the hub filter for this connection.
+func (s *socket) accepts(evt stream.Event) bool {
+	_, ok := s.channelFor(evt)
+	return ok
+}
+
+// channelFor names the subscribed channel evt is delivered on, preferring
+// the most specific one.
+func (s *socket) channelFor(evt stream.Event) (string, bool) {
+	if s.excluded[evt.AuthorID] != "" {
+		return "", false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if evt.Recipient != uuid.Nil {
+		return channelNotifications, evt.Recipient == s.user && s.channels[channelNotifications]
+	}
+	if user := channelUserPrefix + evt.AuthorID.String(); s.channels[user] {
+		return user, true
+	}
+	return channelChirps, s.channels[channelChirps]
+}
+
+// read handles client requests until the connection fails, queueing a
+// reply to each. It gives up on clients that do not read their replies.
+func (s *socket) read(conn *websocket.Conn, replies chan<- models.SocketMessage) error {
+	for {
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		reply := models.SocketMessage{Type: "error", Error: "Invalid request"}
+		var req models.SocketRequest
+		if err := json.Unmarshal(frame, &req); err == nil {
+			reply = s.handle(req)
+		}
+		select {
+		case replies <- reply:
+		default:
+			return errors.New("reply queue full")
+		}
+	}
+}
+
+// handle applies one client request and returns the reply.
+func (s *socket) handle(req models.SocketRequest) models.SocketMessage {
+	switch req.Type {
+	case "ping":
+		return models.SocketMessage{Type: "pong"}
+	case "subscribe", "unsubscribe":
+	default:
+		return models.SocketMessage{Type: "error", Error: "Unknown request type"}
+	}
+	if !validChannel(req.Channel) {
+		return models.SocketMessage{Type: "error", Channel: req.Channel, Error: "Unknown channel"}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if req.Type == "subscribe" {
+		s.channels[req.Channel] = true
+		return models.SocketMessage{Type: "subscribed", Channel: req.Channel}
+	}
+	delete(s.channels, req.Channel)
+	return models.SocketMessage{Type: "unsubscribed", Channel: req.Channel}
+}
+
+func validChannel(channel string) bool {
+	switch channel {
+	case channelChirps, channelNotifications:
+		return true
+	}
+	id, ok := strings.CutPrefix(channel, channelUserPrefix)
+	if !ok {
+		return false
+	}
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
+// hijackable gives the upgrader an http.Hijacker through the middleware
+// response writers, which expose the connection via Unwrap.
+type hijackable struct {
+	http.ResponseWriter
+}
+
+func (h hijackable) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(h.ResponseWriter).Hijack()
+}
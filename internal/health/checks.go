@@ -0,0 +1,24 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// DBPing checks that the database accepts connections.
+func DBPing(db *sql.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// NotShuttingDown fails once shuttingDown reports true.
+func NotShuttingDown(shuttingDown func() bool) CheckFunc {
+	return func(ctx context.Context) error {
+		if shuttingDown() {
+			return errors.New("server is shutting down")
+		}
+		return nil
+	}
+}
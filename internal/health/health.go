@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Component statuses.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+const defaultCheckTimeout = 2 * time.Second
+
+// CheckFunc reports a component's health; nil means healthy.
+type CheckFunc func(ctx context.Context) error
+
+// ComponentResult is the outcome of one check.
+type ComponentResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration,omitempty"`
+}
+
+// Report is the readiness breakdown returned by Checker.Run.
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentResult `json:"components"`
+}
+
+// Checker runs named dependency checks concurrently, each bounded by
+// Timeout.
+type Checker struct {
+	Timeout time.Duration
+
+	mu     sync.RWMutex
+	checks map[string]CheckFunc
+}
+
+func NewChecker() *Checker {
+	return &Checker{
+		Timeout: defaultCheckTimeout,
+		checks:  make(map[string]CheckFunc),
+	}
+}
+
+// Register adds or replaces the check for name.
+func (c *Checker) Register(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// Run executes every check. The report is healthy only if all are.
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	names := make([]string, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	checks := make(map[string]CheckFunc, len(c.checks))
+	for k, v := range c.checks {
+		checks[k] = v
+	}
+	c.mu.RUnlock()
+	sort.Strings(names)
+
+	results := make([]ComponentResult, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.runOne(ctx, checks[name])
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Components: make(map[string]ComponentResult, len(names))}
+	for i, name := range names {
+		report.Components[name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// Brief strips errors and timings, leaving only statuses, so that
+// internal details are not exposed to unauthenticated probes.
+func (r Report) Brief() Report {
+	brief := Report{Status: r.Status, Components: make(map[string]ComponentResult, len(r.Components))}
+	for name, res := range r.Components {
+		brief.Components[name] = ComponentResult{Status: res.Status}
+	}
+	return brief
+}
+
+func (c *Checker) runOne(ctx context.Context, check CheckFunc) ComponentResult {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+
+	res := ComponentResult{Status: StatusOK, Duration: time.Since(start).String()}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
@@ -1,9 +1,11 @@
 package logger
 
 import (
+	"context"
 	"log"
 	"os"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -34,4 +36,33 @@ func init() {
 // Helper for cleanup
 func Sync() {
 	_ = Logger.Sync()
-}
\ No newline at end of file
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying l as the request-scoped logger.
+func NewContext(ctx context.Context, l *zap.SugaredLogger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the request-scoped logger stored by NewContext,
+// falling back to WithTrace(ctx) outside of a request.
+func FromContext(ctx context.Context) *zap.SugaredLogger {
+	if l, ok := ctx.Value(ctxKey{}).(*zap.SugaredLogger); ok {
+		return l
+	}
+	return WithTrace(ctx)
+}
+
+// WithTrace returns Logger annotated with the trace and span IDs of the
+// span in ctx, or Logger itself when ctx carries no span.
+func WithTrace(ctx context.Context) *zap.SugaredLogger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return Logger
+	}
+	return Logger.With(
+		"trace_id", sc.TraceID().String(),
+		"span_id", sc.SpanID().String(),
+	)
+}
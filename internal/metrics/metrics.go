@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "chirpy"
+
+// Registry holds every Chirpy collector. A dedicated registry keeps the
+// output free of collectors registered globally by dependencies.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by route pattern, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency, by route pattern, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	RequestsCancelledTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_cancelled_total",
+		Help:      "Requests abandoned by the client before the handler finished, by route pattern.",
+	}, []string{"route"})
+
+	QueryTimeoutsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_query_timeouts_total",
+		Help:      "Requests whose DB work exceeded the route's query timeout, by route pattern.",
+	}, []string{"route"})
+
+	PanicsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_panics_total",
+		Help:      "Handler panics recovered by the Recover middleware.",
+	})
+
+	RateLimitedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_rate_limited_total",
+		Help:      "Requests rejected with 429 by the rate limiter, by route pattern.",
+	}, []string{"route"})
+
+	ChirpsCreatedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chirps_created_total",
+		Help:      "Chirps successfully created.",
+	})
+
+	UsersCreatedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "users_created_total",
+		Help:      "Users successfully created.",
+	})
+
+	LoginsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts, by result (success or failure).",
+	}, []string{"result"})
+
+	WebhooksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhooks_total",
+		Help:      "Inbound webhooks, by provider and outcome.",
+	}, []string{"provider", "outcome"})
+
+	StreamClients = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stream_clients",
+		Help:      "Clients currently subscribed to the live chirp stream.",
+	})
+
+	StreamDroppedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stream_clients_dropped_total",
+		Help:      "Stream clients disconnected because they fell too far behind.",
+	})
+)
+
+// Login results.
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		RequestsCancelledTotal,
+		QueryTimeoutsTotal,
+		PanicsTotal,
+		RateLimitedTotal,
+		ChirpsCreatedTotal,
+		UsersCreatedTotal,
+		LoginsTotal,
+		WebhooksTotal,
+		StreamClients,
+		StreamDroppedTotal,
+	)
+}
+
+var dbStatsMu sync.Mutex
+
+// RegisterDBStats exports the connection pool statistics of db. Calling it
+// again, when the database is reopened or in tests, replaces the pool
+// previously registered.
+func RegisterDBStats(db *sql.DB) {
+	dbStatsMu.Lock()
+	defer dbStatsMu.Unlock()
+	c := collectors.NewDBStatsCollector(db, namespace)
+	var registered prometheus.AlreadyRegisteredError
+	if err := Registry.Register(c); errors.As(err, &registered) {
+		Registry.Unregister(registered.ExistingCollector)
+		Registry.MustRegister(c)
+	}
+}
+
+// Handler serves the registry in the Prometheus text exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import "net/http"
+
+// Middleware wraps an http.Handler.
+type Middleware func(http.Handler) http.Handler
+
+// Chain composes mw into a single Middleware. The first one is the
+// outermost, so Chain(a, b)(h) is a(b(h)).
+func Chain(mw ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(mw) - 1; i >= 0; i-- {
+			next = mw[i](next)
+		}
+		return next
+	}
+}
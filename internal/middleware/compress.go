@@ -0,0 +1,242 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// DefaultCompressMinSize is the smallest body worth compressing; below it
+// the encoding overhead outweighs the savings.
+const DefaultCompressMinSize = 1024
+
+// CompressOptions configures Compress.
+type CompressOptions struct {
+	// MinSize defaults to DefaultCompressMinSize. Bodies are buffered
+	// up to this size before deciding, unless the handler flushes.
+	MinSize int
+}
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+var encoderPools = map[string]*sync.Pool{
+	"br": {New: func() any { return brotli.NewWriterLevel(io.Discard, 4) }},
+	"gzip": {New: func() any {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}},
+}
+
+// Compress encodes responses with brotli or gzip, whichever the client
+// prefers in Accept-Encoding (brotli on a tie). Responses that are small,
+// already encoded, bodiless, partial, or of an already-compressed media
+// type are passed through unchanged. Flushing is supported, so streaming
+// handlers keep working.
+func Compress(opts CompressOptions) Middleware {
+	if opts.MinSize <= 0 {
+		opts.MinSize = DefaultCompressMinSize
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: opts.MinSize}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding picks "br", "gzip" or "" from an Accept-Encoding
+// header, honouring q-values and "*".
+func negotiateEncoding(header string) string {
+	q := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		weight := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				weight = f
+			}
+		}
+		q[name] = weight
+	}
+
+	best, bestQ := "", 0.0
+	for _, enc := range []string{"br", "gzip"} {
+		weight, ok := q[enc]
+		if !ok {
+			weight, ok = q["*"]
+		}
+		if ok && weight > bestQ {
+			best, bestQ = enc, weight
+		}
+	}
+	return best
+}
+
+// incompressible reports media types that are already compressed.
+func incompressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	switch {
+	case mediaType == "image/svg+xml":
+		return false
+	case strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "video/"),
+		strings.HasPrefix(mediaType, "audio/"):
+		return true
+	}
+	switch mediaType {
+	case "application/zip", "application/gzip", "application/x-gzip",
+		"application/x-brotli", "application/zstd", "application/pdf":
+		return true
+	}
+	return false
+}
+
+// compressWriter buffers the start of the body until it knows whether
+// compression is worthwhile, then commits the headers once.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status    int
+	buf       []byte
+	committed bool
+	enc       encoder
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.committed || cw.status != 0 {
+		return
+	}
+	// Informational responses go straight out.
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	cw.status = code
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.committed {
+		if cw.enc != nil {
+			return cw.enc.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+	cw.buf = append(cw.buf, b...)
+	if len(cw.buf) >= cw.minSize {
+		if err := cw.commit(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// commit sends the headers, choosing compression only if wanted and the
+// response allows it, then writes out anything buffered so far.
+func (cw *compressWriter) commit(want bool) error {
+	cw.committed = true
+	h := cw.Header()
+
+	if want && cw.compressible() {
+		if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+			h.Set("Content-Type", http.DetectContentType(cw.buf))
+		}
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		cw.enc = encoderPools[cw.encoding].Get().(encoder)
+		cw.enc.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(cw.buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buf)
+	}
+	cw.buf = nil
+	return err
+}
+
+func (cw *compressWriter) compressible() bool {
+	switch {
+	case cw.status < 200,
+		cw.status == http.StatusNoContent,
+		cw.status == http.StatusNotModified,
+		cw.status == http.StatusPartialContent:
+		return false
+	}
+	h := cw.Header()
+	return h.Get("Content-Encoding") == "" && !incompressible(h.Get("Content-Type"))
+}
+
+// Flush commits the response, compressed if eligible, and pushes any
+// encoded bytes to the client.
+func (cw *compressWriter) Flush() {
+	if !cw.committed {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		if cw.commit(true) != nil {
+			return
+		}
+	}
+	if cw.enc != nil && cw.enc.Flush() != nil {
+		return
+	}
+	_ = http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Close commits a short response uncompressed and finishes the encoder.
+func (cw *compressWriter) Close() error {
+	if !cw.committed {
+		if cw.status == 0 {
+			return nil
+		}
+		if err := cw.commit(false); err != nil {
+			return err
+		}
+	}
+	if cw.enc == nil {
+		return nil
+	}
+	err := cw.enc.Close()
+	cw.enc.Reset(io.Discard)
+	encoderPools[cw.encoding].Put(cw.enc)
+	cw.enc = nil
+	return err
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -16,7 +16,7 @@ func Logging(next http.Handler) http.Handler {
 
 		duration := time.Since(start)
 
-		logger.Logger.Infow(
+		logger.FromContext(r.Context()).Infow(
 			"HTTP request",
 			"method", r.Method,
 			"path", r.URL.Path,
@@ -37,4 +37,14 @@ type responseRecorder struct {
 func (rec *responseRecorder) WriteHeader(code int) {
 	rec.status = code
 	rec.ResponseWriter.WriteHeader(code)
-}
\ No newline at end of file
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, for
+// example to flush a streaming response.
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+// Flush forwards to the underlying writer when it supports flushing.
+func (rec *responseRecorder) Flush() {
+	_ = http.NewResponseController(rec.ResponseWriter).Flush()
+}
@@ -1,12 +1,34 @@
 package middleware
 
 import (
+	"chirpy/internal/metrics"
+	"context"
+	"errors"
 	"net/http"
-	"chirpy/internal/api"
+	"strconv"
+	"time"
 )
-func MetricsInc(cfg *api.Config, next http.Handler) http.Handler {
+
+// Metrics records request counts and latency for every route. It must
+// wrap the ServeMux itself so that r.Pattern is populated once the mux
+// has matched the request.
+func Metrics(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cfg.FileserverHits.Add(1)
-		next.ServeHTTP(w, r)
+		start := time.Now()
+
+		rr := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rr, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(rr.status)
+
+		metrics.HTTPRequestsTotal.WithLabelValues(route, r.Method, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+		if errors.Is(r.Context().Err(), context.Canceled) {
+			metrics.RequestsCancelledTotal.WithLabelValues(route).Inc()
+		}
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"chirpy/internal/logger"
+	"chirpy/internal/metrics"
+	"chirpy/internal/utils"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recover turns a panic in a handler into a logged 500 instead of a
+// dropped connection. http.ErrAbortHandler is re-raised so the server
+// can abort the response as the handler intended.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &headerTracker{ResponseWriter: w}
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			metrics.PanicsTotal.Inc()
+			logger.FromContext(r.Context()).Errorw("Panic while serving request",
+				"panic", p,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"stack", string(debug.Stack()),
+			)
+
+			// Too late for a clean error once the handler started the body.
+			if !rw.wroteHeader {
+				utils.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error")
+			}
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// headerTracker records whether the response has been started.
+type headerTracker struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (t *headerTracker) WriteHeader(code int) {
+	t.wroteHeader = true
+	t.ResponseWriter.WriteHeader(code)
+}
+
+func (t *headerTracker) Write(b []byte) (int, error) {
+	t.wroteHeader = true
+	return t.ResponseWriter.Write(b)
+}
+
+func (t *headerTracker) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+func (t *headerTracker) Flush() {
+	t.wroteHeader = true
+	_ = http.NewResponseController(t.ResponseWriter).Flush()
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"chirpy/internal/logger"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLen = 128
+
+// RequestID propagates the caller's X-Request-ID, or assigns a new one,
+// echoes it in the response and stores a logger tagged with it in the
+// request context for handlers to retrieve with logger.FromContext.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		// Set before calling next so error responses can include it.
+		w.Header().Set(RequestIDHeader, id)
+
+		reqLogger := logger.WithTrace(r.Context()).With("request_id", id)
+		ctx := logger.NewContext(r.Context(), reqLogger)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID accepts short printable ASCII IDs so that client-supplied
+// values cannot inject control characters into logs or headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package migrate
+
+import (
+	"chirpy/database/schema"
+	"chirpy/internal/config"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/pressly/goose/v3"
+)
+
+// ErrSchemaBehind is returned by CheckCurrent when migrations are pending.
+var ErrSchemaBehind = errors.New("database schema is behind this binary")
+
+// source returns the goose dialect and embedded migrations for driver,
+// as returned by config.DBConfig.Driver.
+func source(driver string) (goose.Dialect, fs.FS, error) {
+	switch driver {
+	case config.DriverPostgres:
+		return goose.DialectPostgres, schema.FS, nil
+	case config.DriverSQLite:
+		fsys, err := fs.Sub(schema.SQLiteFS, "sqlite")
+		return goose.DialectSQLite3, fsys, err
+	default:
+		return "", nil, fmt.Errorf("no migrations for database driver %q", driver)
+	}
+}
+
+// NewProvider returns a goose provider for the embedded migrations of
+// driver.
+func NewProvider(db *sql.DB, driver string) (*goose.Provider, error) {
+	dialect, fsys, err := source(driver)
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(dialect, db, fsys)
+}
+
+// Up applies every pending migration.
+func Up(ctx context.Context, db *sql.DB, driver string) ([]*goose.MigrationResult, error) {
+	p, err := NewProvider(db, driver)
+	if err != nil {
+		return nil, err
+	}
+	return p.Up(ctx)
+}
+
+// Down rolls back the most recent migration.
+func Down(ctx context.Context, db *sql.DB, driver string) (*goose.MigrationResult, error) {
+	p, err := NewProvider(db, driver)
+	if err != nil {
+		return nil, err
+	}
+	return p.Down(ctx)
+}
+
+// Redo rolls back the most recent migration and applies it again.
+func Redo(ctx context.Context, db *sql.DB, driver string) ([]*goose.MigrationResult, error) {
+	p, err := NewProvider(db, driver)
+	if err != nil {
+		return nil, err
+	}
+	down, err := p.Down(ctx)
+	if err != nil {
+		return nil, err
+	}
+	up, err := p.UpByOne(ctx)
+	if err != nil {
+		return []*goose.MigrationResult{down}, err
+	}
+	return []*goose.MigrationResult{down, up}, nil
+}
+
+// Status lists every embedded migration and whether it has been applied.
+func Status(ctx context.Context, db *sql.DB, driver string) ([]*goose.MigrationStatus, error) {
+	p, err := NewProvider(db, driver)
+	if err != nil {
+		return nil, err
+	}
+	return p.Status(ctx)
+}
+
+// Latest returns the newest embedded migration version for driver, i.e.
+// the schema version this binary expects.
+func Latest(driver string) (int64, error) {
+	_, fsys, err := source(driver)
+	if err != nil {
+		return 0, err
+	}
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return 0, err
+	}
+	var latest int64
+	for _, name := range names {
+		v, err := goose.NumericComponent(name)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s: %w", name, err)
+		}
+		latest = max(latest, v)
+	}
+	return latest, nil
+}
+
+// Checker compares a database's schema version with the newest embedded
+// migration. The provider and expected version are resolved once, so
+// each Check is a single query, cheap enough for readiness probes.
+type Checker struct {
+	provider *goose.Provider
+	latest   int64
+}
+
+// NewChecker returns a Checker for db, a database opened for driver.
+func NewChecker(db *sql.DB, driver string) (*Checker, error) {
+	p, err := NewProvider(db, driver)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := Latest(driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Checker{provider: p, latest: latest}, nil
+}
+
+// Check returns ErrSchemaBehind unless the database has applied every
+// embedded migration.
+func (c *Checker) Check(ctx context.Context) error {
+	current, err := c.provider.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+	if current < c.latest {
+		return fmt.Errorf("%w: at version %d, expected %d", ErrSchemaBehind, current, c.latest)
+	}
+	return nil
+}
+
+// CheckCurrent is a one-off Check, for use at startup.
+func CheckCurrent(ctx context.Context, db *sql.DB, driver string) error {
+	c, err := NewChecker(db, driver)
+	if err != nil {
+		return err
+	}
+	return c.Check(ctx)
+}
@@ -2,6 +2,8 @@ package models
 
 
 import (
+	"encoding/json"
+
 	"github.com/google/uuid"
 )
 
@@ -31,6 +33,8 @@ type LoginResponse struct {
 	Token 	  string    `json:"token"`
 	RefreshToken string `json:"refresh_token"`
 	IsChirpyRed bool      `json:"is_chirpy_red"`
+	Role        string    `json:"role"`
+	UnreadNotifications int64 `json:"unread_notifications"`
 }
 
 type UpdateUserRequest struct {
@@ -44,6 +48,7 @@ type UpdateUserResponse struct {
 	CreatedAt string  `json:"created_at"`
 	UpdatedAt string  `json:"updated_at"`
 	IsChirpyRed bool      `json:"is_chirpy_red"`
+	UnreadNotifications int64 `json:"unread_notifications"`
 }
 
 type ChirpRequest struct {
@@ -57,6 +62,8 @@ type ChirpResponse struct {
 	UpdatedAt string    `json:"updated_at"`
 	Body      string    `json:"body"`
 	UserID    uuid.UUID `json:"user_id"`
+	// Hidden is only ever true in responses to the chirp's author.
+	Hidden bool `json:"hidden,omitempty"`
 }
 
 type ErrorResponse struct {
@@ -69,3 +76,114 @@ type PolkaPayload struct {
 		UserID string `json:"user_id"`
 	} `json:"data"`
 }
+
+type ModerationWordRequest struct {
+	Word     string `json:"word"`
+	Severity string `json:"severity"`
+}
+
+type ModerationWordResponse struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt string    `json:"created_at"`
+	UpdatedAt string    `json:"updated_at"`
+	Word      string    `json:"word"`
+	Severity  string    `json:"severity"`
+}
+
+// ChirpRemovedEvent is the stream payload for deleted and hidden chirps.
+type ChirpRemovedEvent struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// SocketRequest is a frame sent by a WebSocket client.
+type SocketRequest struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// SocketMessage is a frame sent to a WebSocket client. Event, ID and Data
+// are set on "event" frames, Error on "error" frames.
+type SocketMessage struct {
+	Type    string          `json:"type"`
+	Channel string          `json:"channel,omitempty"`
+	Event   string          `json:"event,omitempty"`
+	ID      string          `json:"id,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+type ReportRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ReportResponse struct {
+	ID         uuid.UUID      `json:"id"`
+	CreatedAt  string         `json:"created_at"`
+	UpdatedAt  string         `json:"updated_at"`
+	ChirpID    uuid.UUID      `json:"chirp_id"`
+	ReporterID uuid.UUID      `json:"reporter_id"`
+	Reason     string         `json:"reason"`
+	Status     string         `json:"status"`
+	Action     string         `json:"action,omitempty"`
+	ResolvedAt string         `json:"resolved_at,omitempty"`
+	Chirp      *ChirpResponse `json:"chirp,omitempty"`
+}
+
+type ResolveReportRequest struct {
+	Action string `json:"action"`
+	// SuspendFor is a Go duration such as "72h", used by the suspend action.
+	SuspendFor string `json:"suspend_for"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type UserRoleResponse struct {
+	ID             uuid.UUID `json:"id"`
+	Email          string    `json:"email"`
+	UpdatedAt      string    `json:"updated_at"`
+	Role           string    `json:"role"`
+	SuspendedUntil string    `json:"suspended_until,omitempty"`
+}
+
+type RelationshipRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// RelationshipResponse is one entry of the caller's block or mute list.
+type RelationshipResponse struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt string    `json:"created_at"`
+}
+
+// NotificationResponse is one grouped notification: ActorCount people did
+// Type, most recently LastActorID.
+type NotificationResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   string     `json:"created_at"`
+	UpdatedAt   string     `json:"updated_at"`
+	Type        string     `json:"type"`
+	ChirpID     *uuid.UUID `json:"chirp_id,omitempty"`
+	ActorCount  int32      `json:"actor_count"`
+	LastActorID uuid.UUID  `json:"last_actor_id"`
+	Read        bool       `json:"read"`
+}
+
+type NotificationListResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	UnreadCount   int64                  `json:"unread_count"`
+	// NextCursor fetches the following page; empty on the last one.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type MarkNotificationsReadRequest struct {
+	IDs []uuid.UUID `json:"ids"`
+	All bool        `json:"all"`
+}
+
+type MarkNotificationsReadResponse struct {
+	Marked      int64 `json:"marked"`
+	UnreadCount int64 `json:"unread_count"`
+}
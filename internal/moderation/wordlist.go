@@ -0,0 +1,158 @@
+// Package moderation keeps the chirp profanity filter in sync with the
+// words admins manage through the API.
+package moderation
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/logger"
+	"chirpy/internal/profanity"
+	"chirpy/internal/pubsub"
+	"chirpy/internal/store"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultReloadInterval = 30 * time.Second
+
+// Wordlist serves a filter compiled from a base wordlist (the built-in
+// or configured file) plus the words stored in the database. Every
+// instance polls the table, so edits made through one instance reach the
+// others within ReloadInterval without a restart; once attached to a
+// shared PubSub, Announce makes them reload at once.
+type Wordlist struct {
+	db   store.Store
+	base []profanity.Entry
+	opts profanity.Options
+
+	filter atomic.Pointer[profanity.Filter]
+
+	mu      sync.Mutex // serialises Reload
+	version string     // fingerprint of the stored words last compiled
+
+	bus     pubsub.PubSub
+	changed chan struct{} // wakes Run when another instance announces an edit
+
+	ReloadInterval time.Duration
+}
+
+// NewWordlist compiles base, with SeverityMask, into the initial filter.
+// Stored words are added by the first Reload.
+func NewWordlist(db store.Store, base []string, opts profanity.Options) (*Wordlist, error) {
+	w := &Wordlist{
+		db:             db,
+		opts:           opts,
+		changed:        make(chan struct{}, 1),
+		ReloadInterval: defaultReloadInterval,
+	}
+	for _, word := range base {
+		w.base = append(w.base, profanity.Entry{Word: word, Severity: profanity.SeverityMask})
+	}
+	f, err := profanity.NewEntries(w.base, opts)
+	if err != nil {
+		return nil, err
+	}
+	w.filter.Store(f)
+	return w, nil
+}
+
+// Filter returns the current filter.
+func (w *Wordlist) Filter() *profanity.Filter {
+	return w.filter.Load()
+}
+
+// Reload recompiles the filter if the stored words changed since the last
+// load, and reports whether it did. Storing a base word with
+// SeverityBlock makes it block.
+func (w *Wordlist) Reload(ctx context.Context) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	words, err := w.db.ListModerationWords(ctx)
+	if err != nil {
+		return false, err
+	}
+	version := fingerprint(words)
+	if version == w.version {
+		return false, nil
+	}
+
+	entries := slices.Clone(w.base)
+	for _, word := range words {
+		entries = append(entries, profanity.Entry{Word: word.Word, Severity: profanity.Severity(word.Severity)})
+	}
+	f, err := profanity.NewEntries(entries, w.opts)
+	if err != nil {
+		return false, err
+	}
+	w.filter.Store(f)
+	w.version = version
+	return true, nil
+}
+
+// Attach subscribes to wordlist announcements on ps, which Run then
+// answers with a reload, and lets Announce publish there. Call it before
+// Run.
+func (w *Wordlist) Attach(ps pubsub.PubSub) error {
+	_, err := ps.Subscribe(pubsub.TopicWordlist, func([]byte) {
+		select {
+		case w.changed <- struct{}{}:
+		default: // a reload is already due
+		}
+	})
+	if err != nil {
+		return err
+	}
+	w.bus = ps
+	return nil
+}
+
+// Announce tells the other instances attached to the same PubSub that
+// the stored words changed. Without one it does nothing.
+func (w *Wordlist) Announce(ctx context.Context) error {
+	if w.bus == nil {
+		return nil
+	}
+	return w.bus.Publish(ctx, pubsub.TopicWordlist, nil)
+}
+
+// Run reloads every ReloadInterval, and when an edit is announced, until
+// ctx is cancelled.
+func (w *Wordlist) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.ReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.changed:
+		}
+		changed, err := w.Reload(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Logger.Warnw("Moderation wordlist reload failed", "error", err)
+			}
+			continue
+		}
+		if changed {
+			logger.Logger.Infow("Moderation wordlist reloaded")
+		}
+	}
+}
+
+// fingerprint identifies a set of stored words; any insert, update or
+// delete changes it.
+func fingerprint(words []database.ModerationWord) string {
+	h := sha256.New()
+	for _, w := range words {
+		h.Write(w.ID[:])
+		h.Write([]byte(strconv.FormatInt(w.UpdatedAt.UnixNano(), 10)))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
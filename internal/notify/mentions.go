@@ -0,0 +1,28 @@
+package notify
+
+import "regexp"
+
+// MaxMentions caps how many users one chirp can notify, so a chirp cannot
+// be used to spam everyone.
+const MaxMentions = 10
+
+// Users have no handles yet, so a mention is "@" followed by an email
+// address: "thanks @alice@example.com!".
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w.@+-])@([\w.%+-]+@[\w-]+(?:\.[\w-]+)+)`)
+
+// Mentions returns the email addresses mentioned in body, in order of
+// first appearance, without repeats and at most MaxMentions of them.
+func Mentions(body string) []string {
+	var emails []string
+	seen := make(map[string]bool)
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		if email := m[1]; !seen[email] {
+			seen[email] = true
+			emails = append(emails, email)
+			if len(emails) == MaxMentions {
+				break
+			}
+		}
+	}
+	return emails
+}
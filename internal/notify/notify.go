@@ -0,0 +1,116 @@
+// Package notify records notifications for the actions that concern a
+// user, grouping repeats of the same kind on the same chirp.
+package notify
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification types, matching the notifications.type check constraint.
+const (
+	TypeMention = "mention"
+	TypeReply   = "reply"
+	TypeLike    = "like"
+	TypeFollow  = "follow"
+)
+
+// Event is one action that UserID should hear about.
+type Event struct {
+	Type    string
+	UserID  uuid.UUID // who is notified
+	ActorID uuid.UUID // who acted
+	// ChirpID is the chirp mentioned in, replied to or liked; unset for
+	// follows.
+	ChirpID uuid.NullUUID
+}
+
+// Recorder is satisfied by database.Queries and store.Store.
+type Recorder interface {
+	GetBlock(ctx context.Context, arg database.GetBlockParams) (database.Block, error)
+	GetUnreadNotificationGroup(ctx context.Context, arg database.GetUnreadNotificationGroupParams) (database.Notification, error)
+	CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.Notification, error)
+	AddNotificationActor(ctx context.Context, arg database.AddNotificationActorParams) (int64, error)
+	BumpNotification(ctx context.Context, arg database.BumpNotificationParams) (database.Notification, error)
+}
+
+// Record adds evt to the recipient's unread notification of the same type
+// on the same chirp, or starts a new one if there is none. Pass queries
+// bound to the transaction performing the action so both commit together.
+//
+// It reports whether the notification changed: acting on yourself, acting
+// between users where either has blocked the other, or repeating an action
+// already counted in the group, does not notify.
+func Record(ctx context.Context, q Recorder, evt Event) (database.Notification, bool, error) {
+	if evt.ActorID == evt.UserID {
+		return database.Notification{}, false, nil
+	}
+	if blocked, err := eitherBlocked(ctx, q, evt.UserID, evt.ActorID); err != nil || blocked {
+		return database.Notification{}, false, err
+	}
+	now := time.Now().UTC()
+
+	group := database.GetUnreadNotificationGroupParams{
+		UserID:  evt.UserID,
+		Type:    evt.Type,
+		ChirpID: evt.ChirpID,
+	}
+	n, err := q.GetUnreadNotificationGroup(ctx, group)
+	if errors.Is(err, sql.ErrNoRows) {
+		n, err = q.CreateNotification(ctx, database.CreateNotificationParams{
+			ID:          uuid.New(),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			UserID:      evt.UserID,
+			Type:        evt.Type,
+			ChirpID:     evt.ChirpID,
+			LastActorID: evt.ActorID,
+		})
+		if err == nil {
+			_, err = q.AddNotificationActor(ctx, database.AddNotificationActorParams{
+				NotificationID: n.ID, ActorID: evt.ActorID, CreatedAt: now,
+			})
+			return n, err == nil, err
+		}
+		// A concurrent action started the group first; join it.
+		if errors.Is(err, sql.ErrNoRows) {
+			n, err = q.GetUnreadNotificationGroup(ctx, group)
+		}
+	}
+	if err != nil {
+		return database.Notification{}, false, err
+	}
+
+	added, err := q.AddNotificationActor(ctx, database.AddNotificationActorParams{
+		NotificationID: n.ID, ActorID: evt.ActorID, CreatedAt: now,
+	})
+	if err != nil || added == 0 {
+		return n, false, err
+	}
+	n, err = q.BumpNotification(ctx, database.BumpNotificationParams{
+		ID: n.ID, LastActorID: evt.ActorID, UpdatedAt: now,
+	})
+	return n, err == nil, err
+}
+
+// eitherBlocked reports whether a has blocked b or b has blocked a.
+func eitherBlocked(ctx context.Context, q Recorder, a, b uuid.UUID) (bool, error) {
+	for _, p := range []database.GetBlockParams{
+		{BlockerID: a, BlockedID: b},
+		{BlockerID: b, BlockedID: a},
+	} {
+		_, err := q.GetBlock(ctx, p)
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return false, err
+		}
+	}
+	return false, nil
+}
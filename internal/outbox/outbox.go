@@ -0,0 +1,58 @@
+package outbox
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types written to the outbox.
+const (
+	EventChirpCreated = "chirp.created"
+	EventChirpDeleted = "chirp.deleted"
+	EventChirpHidden  = "chirp.hidden"
+)
+
+// Event is a pending outbox row handed to subscribers by the relay.
+type Event struct {
+	ID          uuid.UUID
+	Type        string
+	AggregateID uuid.UUID
+	Payload     json.RawMessage
+	CreatedAt   time.Time
+	Attempts    int32
+}
+
+// ChirpPayload is the payload for chirp.created, chirp.deleted and
+// chirp.hidden events.
+type ChirpPayload struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Body      string    `json:"body,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Inserter is satisfied by database.Queries and store.Store.
+type Inserter interface {
+	InsertOutboxEvent(ctx context.Context, arg database.InsertOutboxEventParams) error
+}
+
+// Enqueue writes an event row using q. Pass queries bound to the
+// transaction making the data change so both commit together.
+func Enqueue(ctx context.Context, q Inserter, eventType string, aggregateID uuid.UUID, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return q.InsertOutboxEvent(ctx, database.InsertOutboxEventParams{
+		ID:          uuid.New(),
+		CreatedAt:   time.Now().UTC(),
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		Payload:     data,
+	})
+}
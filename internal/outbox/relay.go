@@ -0,0 +1,201 @@
+package outbox
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/logger"
+	"chirpy/internal/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Handler processes a single event. Returning an error leaves the event
+// pending so it is retried on a later poll; handlers must be idempotent
+// because delivery is at-least-once.
+type Handler func(ctx context.Context, evt Event) error
+
+// AllEvents subscribes a handler to every event type.
+const AllEvents = "*"
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+	defaultMaxAttempts  = 10
+)
+
+// Relay polls the outbox table and publishes pending events to
+// in-process subscribers, marking each row published once every
+// subscriber has handled it.
+type Relay struct {
+	db     *sql.DB
+	driver string
+
+	PollInterval time.Duration
+	BatchSize    int32
+	MaxAttempts  int32
+	// SkipLocked claims rows with FOR UPDATE SKIP LOCKED so that several
+	// relays can share a Postgres database. SQLite has no row locks and
+	// serializes writers anyway, so set it for Postgres only.
+	SkipLocked bool
+
+	mu          sync.RWMutex
+	subscribers map[string][]Handler
+
+	// lastSuccess is the UnixNano time of the last poll that completed
+	// without error; zero until Run starts.
+	lastSuccess atomic.Int64
+}
+
+// NewRelay returns a relay for db, a database opened with the
+// database/sql driver named driver ("postgres" or "sqlite"), which is
+// recorded on query spans.
+func NewRelay(db *sql.DB, driver string) *Relay {
+	return &Relay{
+		db:           db,
+		driver:       driver,
+		PollInterval: defaultPollInterval,
+		BatchSize:    defaultBatchSize,
+		MaxAttempts:  defaultMaxAttempts,
+		subscribers:  make(map[string][]Handler),
+	}
+}
+
+// Subscribe registers h for eventType, or for every type with AllEvents.
+func (r *Relay) Subscribe(eventType string, h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers[eventType] = append(r.subscribers[eventType], h)
+}
+
+// Run polls until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	logger.Logger.Infow("Outbox relay started", "poll_interval", r.PollInterval)
+
+	ticker := time.NewTicker(r.PollInterval)
+	defer ticker.Stop()
+
+	r.lastSuccess.Store(time.Now().UnixNano())
+	defer r.lastSuccess.Store(0)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Logger.Infow("Outbox relay stopped")
+			return
+		case <-ticker.C:
+			for {
+				n, err := r.ProcessBatch(ctx)
+				if err != nil {
+					if !errors.Is(err, context.Canceled) {
+						logger.Logger.Errorw("Outbox batch failed", "error", err)
+					}
+					break
+				}
+				r.lastSuccess.Store(time.Now().UnixNano())
+				// Keep draining while batches come back full.
+				if n < int(r.BatchSize) {
+					break
+				}
+			}
+		}
+	}
+}
+
+// Check reports an error when the relay is not running or has not
+// completed a poll in the last few intervals. It is meant for readiness.
+func (r *Relay) Check(ctx context.Context) error {
+	last := r.lastSuccess.Load()
+	if last == 0 {
+		return errors.New("outbox relay is not running")
+	}
+	if since := time.Since(time.Unix(0, last)); since > 3*r.PollInterval {
+		return fmt.Errorf("outbox relay last polled successfully %s ago", since.Round(time.Second))
+	}
+	return nil
+}
+
+// ProcessBatch claims up to BatchSize pending events, dispatches them and
+// records the outcome in a single transaction. It returns the number of
+// events claimed.
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(tracing.WrapDBTX(tx, r.driver))
+	var rows []database.OutboxEvent
+	if r.SkipLocked {
+		rows, err = qtx.ClaimPendingOutboxEvents(ctx, database.ClaimPendingOutboxEventsParams{
+			Attempts: r.MaxAttempts,
+			Limit:    r.BatchSize,
+		})
+	} else {
+		rows, err = qtx.ListPendingOutboxEvents(ctx, database.ListPendingOutboxEventsParams{
+			Attempts: r.MaxAttempts,
+			Limit:    r.BatchSize,
+		})
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for _, row := range rows {
+		evt := Event{
+			ID:          row.ID,
+			Type:        row.EventType,
+			AggregateID: row.AggregateID,
+			Payload:     row.Payload,
+			CreatedAt:   row.CreatedAt,
+			Attempts:    row.Attempts,
+		}
+
+		if err := r.dispatch(ctx, evt); err != nil {
+			logger.Logger.Warnw("Outbox event delivery failed",
+				"event_id", evt.ID,
+				"event_type", evt.Type,
+				"attempts", evt.Attempts+1,
+				"error", err,
+			)
+			if err := qtx.RecordOutboxEventFailure(ctx, database.RecordOutboxEventFailureParams{
+				ID:        evt.ID,
+				LastError: sql.NullString{String: err.Error(), Valid: true},
+			}); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		if err := qtx.MarkOutboxEventPublished(ctx, database.MarkOutboxEventPublishedParams{
+			ID:          evt.ID,
+			PublishedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+func (r *Relay) dispatch(ctx context.Context, evt Event) error {
+	r.mu.RLock()
+	handlers := append([]Handler{}, r.subscribers[evt.Type]...)
+	handlers = append(handlers, r.subscribers[AllEvents]...)
+	r.mu.RUnlock()
+
+	var errs []error
+	for _, h := range handlers {
+		if err := h(ctx, evt); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,90 @@
+package profanity
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// stripMarks returns a transformer removing combining marks after
+// canonical decomposition, so "é" compares equal to "e". A chain keeps
+// state between calls, so each caller needs its own.
+func stripMarks() transform.Transformer {
+	return transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+}
+
+// confusables maps look-alike letters from other scripts to the Latin
+// letter they imitate. It covers the homoglyphs commonly used to dodge
+// filters, not the full Unicode confusables table.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i',
+	'ї': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w', 'һ': 'h',
+	'ӏ': 'l',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v',
+	'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w', 'ς': 's',
+	// Latin look-alikes that survive NFKC
+	'ı': 'i', 'ł': 'l', 'ø': 'o', 'đ': 'd', 'ħ': 'h', 'ŧ': 't',
+}
+
+// leet maps digits and symbols used as letters. '1' and '|' stand for
+// either "i" or "l", so they are expanded by variants instead.
+var leet = map[rune]rune{
+	'0': 'o', '3': 'e', '4': 'a', '5': 's', '6': 'g', '7': 't', '8': 'b',
+	'9': 'g', '@': 'a', '$': 's', '!': 'i', '+': 't', '€': 'e', '£': 'l',
+}
+
+// fold lower-cases s and maps compatibility forms, accents and
+// confusable letters onto plain Latin.
+func fold(s string) string {
+	s = norm.NFKC.String(s)
+	s = strings.ToLower(s)
+	if out, _, err := transform.String(stripMarks(), s); err == nil {
+		s = out
+	}
+	return strings.Map(func(r rune) rune {
+		if c, ok := confusables[r]; ok {
+			return c
+		}
+		return r
+	}, s)
+}
+
+// deleet returns the spellings of s with leetspeak decoded, one per
+// reading of the ambiguous '1' and '|'.
+func deleet(s string) []string {
+	decode := func(ambiguous rune) string {
+		return strings.Map(func(r rune) rune {
+			if c, ok := leet[r]; ok {
+				return c
+			}
+			if r == '1' || r == '|' {
+				return ambiguous
+			}
+			return r
+		}, s)
+	}
+	asI, asL := decode('i'), decode('l')
+	if asI == asL {
+		return []string{asI}
+	}
+	return []string{asI, asL}
+}
+
+// squeeze collapses runs of the same rune: "fooool" becomes "fol".
+func squeeze(s string) string {
+	var b strings.Builder
+	var last rune = -1
+	for _, r := range s {
+		if r != last {
+			b.WriteRune(r)
+		}
+		last = r
+	}
+	return b.String()
+}
@@ -0,0 +1,262 @@
+// Package profanity finds banned words in user text despite case,
+// accents, look-alike letters, leetspeak and stretched spellings, and
+// masks them or rejects the text.
+package profanity
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Strategy decides what happens to text containing a banned word.
+type Strategy string
+
+const (
+	// StrategyMask replaces each banned word with "****".
+	StrategyMask Strategy = "mask"
+	// StrategyAsterisks replaces each banned word with one "*" per
+	// character, preserving the text's length.
+	StrategyAsterisks Strategy = "asterisks"
+	// StrategyReject refuses the text with ErrProfane.
+	StrategyReject Strategy = "reject"
+)
+
+// Severity overrides the filter's Strategy for a single word.
+type Severity string
+
+const (
+	// SeverityMask words are handled by the filter's Strategy.
+	SeverityMask Severity = "mask"
+	// SeverityBlock words reject the text whatever the Strategy.
+	SeverityBlock Severity = "block"
+)
+
+// Entry is a banned word with its severity.
+type Entry struct {
+	Word     string
+	Severity Severity
+}
+
+// ErrProfane is returned by Clean under StrategyReject, or for text
+// containing a SeverityBlock word.
+var ErrProfane = errors.New("text contains prohibited words")
+
+// DefaultWords is the wordlist used when none is configured.
+var DefaultWords = []string{"kerfuffle", "sharbert", "fornax"}
+
+// Options tunes matching and replacement.
+type Options struct {
+	// Strategy defaults to StrategyMask.
+	Strategy Strategy
+	// Leetspeak decodes digits and symbols used as letters ("k3rfuffl3").
+	Leetspeak bool
+	// FoldRepeats ignores stretched letters ("kerfuuuffle"). Words that
+	// differ only by a doubled letter then match each other.
+	FoldRepeats bool
+}
+
+// tokenPattern finds candidate words: runs of letters, marks and digits
+// plus the symbols that leetspeak uses as letters.
+var tokenPattern = regexp.MustCompile(`[\p{L}\p{M}\p{N}@$!|+€£]+`)
+
+// Filter matches text against a wordlist. It is immutable and safe for
+// concurrent use.
+type Filter struct {
+	opts  Options
+	words map[string]Severity
+}
+
+// New compiles words into a Filter, all with SeverityMask.
+func New(words []string, opts Options) (*Filter, error) {
+	entries := make([]Entry, len(words))
+	for i, w := range words {
+		entries[i] = Entry{Word: w, Severity: SeverityMask}
+	}
+	return NewEntries(entries, opts)
+}
+
+// NewEntries compiles entries into a Filter. When two entries share a
+// canonical spelling, SeverityBlock wins.
+func NewEntries(entries []Entry, opts Options) (*Filter, error) {
+	switch opts.Strategy {
+	case "":
+		opts.Strategy = StrategyMask
+	case StrategyMask, StrategyAsterisks, StrategyReject:
+	default:
+		return nil, fmt.Errorf("unknown profanity strategy %q", opts.Strategy)
+	}
+
+	f := &Filter{opts: opts, words: make(map[string]Severity, len(entries))}
+	for _, e := range entries {
+		switch e.Severity {
+		case SeverityMask, SeverityBlock:
+		default:
+			return nil, fmt.Errorf("unknown severity %q for %q", e.Severity, e.Word)
+		}
+		for _, form := range f.forms(strings.TrimSpace(e.Word)) {
+			if form != "" && f.words[form] != SeverityBlock {
+				f.words[form] = e.Severity
+			}
+		}
+	}
+	return f, nil
+}
+
+var defaultFilter = func() *Filter {
+	f, err := New(DefaultWords, Options{Leetspeak: true, FoldRepeats: true})
+	if err != nil {
+		panic(err)
+	}
+	return f
+}()
+
+// Default returns a masking filter for DefaultWords with leetspeak and
+// repeat folding enabled.
+func Default() *Filter {
+	return defaultFilter
+}
+
+// Strategy reports how the filter treats matches.
+func (f *Filter) Strategy() Strategy {
+	return f.opts.Strategy
+}
+
+// forms returns the canonical spellings of s to compare.
+func (f *Filter) forms(s string) []string {
+	folded := fold(s)
+	forms := []string{folded}
+	if f.opts.Leetspeak {
+		forms = deleet(folded)
+	}
+	if f.opts.FoldRepeats {
+		for i, form := range forms {
+			forms[i] = squeeze(form)
+		}
+	}
+	return forms
+}
+
+// ValidWord reports whether w can be matched: a single word, optionally
+// written with the symbols leetspeak uses.
+func ValidWord(w string) bool {
+	return w != "" && tokenPattern.FindString(w) == w
+}
+
+// banned returns the severity of s, or "" if s is not banned.
+func (f *Filter) banned(s string) Severity {
+	var sev Severity
+	for _, form := range f.forms(s) {
+		if v, ok := f.words[form]; ok && sev != SeverityBlock {
+			sev = v
+		}
+	}
+	return sev
+}
+
+// span is a banned word's byte range in the original text.
+type span struct {
+	start, end int
+	severity   Severity
+}
+
+// find returns the banned words in s. A token matches as a whole
+// ("$harbert") or without the symbols around it ("kerfuffle!"), in which
+// case only the word itself is reported.
+func (f *Filter) find(s string) []span {
+	var spans []span
+	for _, loc := range tokenPattern.FindAllStringIndex(s, -1) {
+		token := s[loc[0]:loc[1]]
+		if sev := f.banned(token); sev != "" {
+			spans = append(spans, span{loc[0], loc[1], sev})
+			continue
+		}
+		core := strings.TrimFunc(token, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsMark(r) && !unicode.IsDigit(r)
+		})
+		if core == "" || core == token {
+			continue
+		}
+		if sev := f.banned(core); sev != "" {
+			start := loc[0] + strings.Index(token, core)
+			spans = append(spans, span{start, start + len(core), sev})
+		}
+	}
+	return spans
+}
+
+// Matches returns the banned words found in s, as written.
+func (f *Filter) Matches(s string) []string {
+	var out []string
+	for _, sp := range f.find(s) {
+		out = append(out, s[sp.start:sp.end])
+	}
+	return out
+}
+
+// Clean applies the filter's strategy to s. It returns s unchanged and
+// ErrProfane if s contains a SeverityBlock word, or any banned word under
+// StrategyReject.
+func (f *Filter) Clean(s string) (string, error) {
+	spans := f.find(s)
+	if len(spans) == 0 {
+		return s, nil
+	}
+	if f.opts.Strategy == StrategyReject {
+		return s, ErrProfane
+	}
+	for _, sp := range spans {
+		if sp.severity == SeverityBlock {
+			return s, ErrProfane
+		}
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, sp := range spans {
+		b.WriteString(s[last:sp.start])
+		if f.opts.Strategy == StrategyAsterisks {
+			b.WriteString(strings.Repeat("*", utf8.RuneCountInString(s[sp.start:sp.end])))
+		} else {
+			b.WriteString("****")
+		}
+		last = sp.end
+	}
+	b.WriteString(s[last:])
+	return b.String(), nil
+}
+
+// LoadWords reads one word per line, skipping blank lines and lines
+// starting with "#".
+func LoadWords(r io.Reader) ([]string, error) {
+	var words []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, sc.Err()
+}
+
+// LoadFile reads a wordlist file in the LoadWords format.
+func LoadFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening wordlist: %w", err)
+	}
+	defer f.Close()
+	words, err := LoadWords(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading wordlist %s: %w", path, err)
+	}
+	return words, nil
+}
@@ -0,0 +1,149 @@
+package pubsub
+
+import (
+	"chirpy/internal/logger"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	minReconnectInterval = time.Second
+	maxReconnectInterval = time.Minute
+	// listenerPing keeps the idle LISTEN connection from being dropped
+	// by proxies and notices a dead one.
+	listenerPing = 90 * time.Second
+	// maxNotifyPayload is Postgres's limit for a NOTIFY payload.
+	maxNotifyPayload = 8000
+)
+
+// ErrPayloadTooLarge is returned by Postgres.Publish for payloads that do
+// not fit in a NOTIFY. Local subscribers have still received them.
+var ErrPayloadTooLarge = errors.New("pubsub: payload too large for NOTIFY")
+
+// Postgres is a PubSub shared by every instance using the same database.
+// Publish runs pg_notify on the pool; a dedicated lib/pq Listener
+// connection LISTENs on each subscribed topic and hands notifications
+// from other instances to local subscribers. Run must be running for
+// them to arrive.
+type Postgres struct {
+	db       *sql.DB
+	listener *pq.Listener
+	local    *Memory
+	// origin tags this instance's notifications so that it can skip them
+	// when they come back; Publish already delivered them locally.
+	origin string
+
+	mu sync.Mutex // serialises LISTEN and UNLISTEN with local subscribers
+}
+
+// NewPostgres returns a PubSub that publishes through db and listens on a
+// separate connection opened from url, reconnecting when it drops.
+func NewPostgres(db *sql.DB, url string) *Postgres {
+	p := &Postgres{
+		db:     db,
+		local:  NewMemory(),
+		origin: uuid.NewString(),
+	}
+	p.listener = pq.NewListener(url, minReconnectInterval, maxReconnectInterval, p.logEvent)
+	return p
+}
+
+// Publish delivers payload to local subscribers, then notifies the other
+// instances.
+func (p *Postgres) Publish(ctx context.Context, topic string, payload []byte) error {
+	_ = p.local.Publish(ctx, topic, payload)
+
+	msg := p.origin + ":" + string(payload)
+	if len(msg) >= maxNotifyPayload {
+		return fmt.Errorf("%w: %d bytes on %s", ErrPayloadTooLarge, len(payload), topic)
+	}
+	_, err := p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", topic, msg)
+	return err
+}
+
+// Subscribe registers fn and LISTENs on topic if this is its first
+// subscriber. It blocks until the listener connection is up.
+func (p *Postgres) Subscribe(topic string, fn Handler) (func(), error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.local.subscribers(topic) == 0 {
+		if err := p.listener.Listen(topic); err != nil && !errors.Is(err, pq.ErrChannelAlreadyOpen) {
+			return nil, fmt.Errorf("listening on %s: %w", topic, err)
+		}
+	}
+	unsubscribe, _ := p.local.Subscribe(topic, fn)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			unsubscribe()
+			if p.local.subscribers(topic) > 0 {
+				return
+			}
+			if err := p.listener.Unlisten(topic); err != nil && !errors.Is(err, pq.ErrChannelNotOpen) {
+				logger.Logger.Warnw("Failed to stop listening", "topic", topic, "error", err)
+			}
+		})
+	}, nil
+}
+
+// Run delivers notifications from other instances until ctx is cancelled
+// or Close is called.
+func (p *Postgres) Run(ctx context.Context) {
+	ping := time.NewTicker(listenerPing)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n, ok := <-p.listener.Notify:
+			if !ok {
+				return
+			}
+			if n == nil {
+				// Sent after a reconnect: anything published meanwhile
+				// is lost.
+				logger.Logger.Warnw("Pub/sub listener reconnected; notifications may have been missed")
+				continue
+			}
+			origin, payload, found := strings.Cut(n.Extra, ":")
+			if !found || origin == p.origin {
+				continue
+			}
+			_ = p.local.Publish(ctx, n.Channel, []byte(payload))
+		case <-ping.C:
+			// Ping waits for a reply that queues behind notifications,
+			// so it must not block this loop.
+			go func() {
+				if err := p.listener.Ping(); err != nil {
+					logger.Logger.Debugw("Pub/sub listener ping failed", "error", err)
+				}
+			}()
+		}
+	}
+}
+
+// Close closes the listener connection and ends Run.
+func (p *Postgres) Close() error {
+	return p.listener.Close()
+}
+
+// logEvent reports listener connection failures; Run logs reconnects.
+func (p *Postgres) logEvent(event pq.ListenerEventType, err error) {
+	switch event {
+	case pq.ListenerEventDisconnected:
+		logger.Logger.Warnw("Pub/sub listener disconnected", "error", err)
+	case pq.ListenerEventConnectionAttemptFailed:
+		logger.Logger.Warnw("Pub/sub listener failed to connect", "error", err)
+	}
+}
@@ -0,0 +1,92 @@
+// Package pubsub carries events between Chirpy instances. Memory serves
+// a single instance; Postgres uses LISTEN/NOTIFY so replicas sharing a
+// database hear each other without a separate message broker.
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// Topics published by Chirpy.
+const (
+	// TopicStream carries chirp and moderation events for live clients.
+	TopicStream = "chirpy_stream"
+	// TopicWordlist announces moderation wordlist edits, so instances
+	// reload their compiled filter.
+	TopicWordlist = "chirpy_wordlist"
+)
+
+// Handler receives one published payload. It runs on the delivering
+// goroutine, so it must return quickly and not call back into the PubSub.
+type Handler func(payload []byte)
+
+// PubSub delivers payloads published on a topic to its subscribers.
+// Delivery is best effort: a subscriber that was disconnected may miss
+// messages, so anything that must not be lost also needs another path,
+// such as periodic polling.
+type PubSub interface {
+	// Publish hands payload to the subscribers of topic on this instance
+	// before returning and, for shared implementations, sends it to the
+	// other instances.
+	Publish(ctx context.Context, topic string, payload []byte) error
+	// Subscribe calls fn for every payload published on topic until the
+	// returned function is called.
+	Subscribe(topic string, fn Handler) (unsubscribe func(), err error)
+}
+
+// Memory is a PubSub within one process.
+type Memory struct {
+	mu   sync.RWMutex
+	subs map[string]map[*Handler]struct{}
+}
+
+// NewMemory returns an empty in-process PubSub.
+func NewMemory() *Memory {
+	return &Memory{subs: make(map[string]map[*Handler]struct{})}
+}
+
+// Publish calls every subscriber of topic in turn. It never fails.
+func (m *Memory) Publish(_ context.Context, topic string, payload []byte) error {
+	m.mu.RLock()
+	handlers := make([]Handler, 0, len(m.subs[topic]))
+	for fn := range m.subs[topic] {
+		handlers = append(handlers, *fn)
+	}
+	m.mu.RUnlock()
+
+	for _, fn := range handlers {
+		fn(payload)
+	}
+	return nil
+}
+
+// Subscribe registers fn for topic. It never fails.
+func (m *Memory) Subscribe(topic string, fn Handler) (func(), error) {
+	key := &fn
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.subs[topic] == nil {
+		m.subs[topic] = make(map[*Handler]struct{})
+	}
+	m.subs[topic][key] = struct{}{}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			delete(m.subs[topic], key)
+			if len(m.subs[topic]) == 0 {
+				delete(m.subs, topic)
+			}
+		})
+	}, nil
+}
+
+// subscribers reports how many handlers topic has.
+func (m *Memory) subscribers(topic string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.subs[topic])
+}
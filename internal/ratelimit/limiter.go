@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"chirpy/internal/auth"
+	"chirpy/internal/logger"
+	"chirpy/internal/metrics"
+	"chirpy/internal/utils"
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultSweepInterval = time.Minute
+
+// KeyFunc identifies the client a request is counted against.
+type KeyFunc func(r *http.Request) string
+
+// Limiter enforces per-route limits, keyed by ServeMux pattern such as
+// "POST /api/chirps".
+type Limiter struct {
+	store Store
+	rules map[string]Limit
+	key   KeyFunc
+
+	SweepInterval time.Duration
+}
+
+func New(store Store, rules map[string]Limit, key KeyFunc) *Limiter {
+	return &Limiter{
+		store:         store,
+		rules:         rules,
+		key:           key,
+		SweepInterval: defaultSweepInterval,
+	}
+}
+
+// Wrap limits next under the rule for pattern. Routes without a rule are
+// returned unchanged.
+//
+// Every limited response carries RateLimit-Policy, RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers; rejected requests get
+// 429 with Retry-After. If the store fails the request is let through,
+// so a database hiccup degrades limiting rather than the API.
+func (l *Limiter) Wrap(pattern string, next http.Handler) http.Handler {
+	limit, ok := l.rules[pattern]
+	if !ok {
+		return next
+	}
+	policy := strconv.Itoa(int(limit.capacity())) + ";w=" + strconv.Itoa(int(limit.Per.Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := logger.FromContext(r.Context())
+		key := pattern + "|" + l.key(r)
+
+		res, err := l.store.Take(r.Context(), key, limit, time.Now().UTC())
+		if err != nil {
+			log.Warnw("Rate limit check failed; allowing request",
+				"route", pattern,
+				"error", err,
+			)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Policy", policy)
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+
+		if !res.Allowed {
+			metrics.RateLimitedTotal.WithLabelValues(pattern).Inc()
+			log.Infow("Rate limit exceeded",
+				"route", pattern,
+				"retry_after", res.RetryAfter,
+			)
+			h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+			utils.RespondWithError(w, http.StatusTooManyRequests, "Too Many Requests")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Run periodically drops buckets that have had time to refill, until ctx
+// is cancelled.
+func (l *Limiter) Run(ctx context.Context) {
+	var idle time.Duration
+	for _, limit := range l.rules {
+		idle = max(idle, limit.refillTime())
+	}
+
+	ticker := time.NewTicker(l.SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.store.Sweep(ctx, time.Now().UTC().Add(-idle)); err != nil && ctx.Err() == nil {
+				logger.Logger.Warnw("Rate limit sweep failed", "error", err)
+			}
+		}
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// UserOrIP keys requests with a valid access token by user ID and all
+// others by client IP. With trustForwardedFor the client IP is the last
+// X-Forwarded-For entry, the one added by the proxy in front of Chirpy;
+// only enable it behind such a proxy, since clients can forge the rest.
+func UserOrIP(jwtSecret string, trustForwardedFor bool) KeyFunc {
+	return func(r *http.Request) string {
+		if token, err := auth.GetBearerToken(r.Header); err == nil {
+			if userID, err := auth.ValidateJWT(token, jwtSecret); err == nil {
+				return "user:" + userID.String()
+			}
+		}
+		return "ip:" + clientIP(r, trustForwardedFor)
+	}
+}
+
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+			hops := strings.Split(xff[len(xff)-1], ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,80 @@
+// Package ratelimit implements token-bucket rate limiting for HTTP
+// routes, keyed by authenticated user or client IP, with buckets kept in
+// process memory or in the database so replicas share them.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit allows Requests per Per on average, with bursts of up to Burst
+// requests (Requests when zero).
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+// rate is the refill rate in tokens per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// refillTime is how long an empty bucket takes to fill up. A bucket left
+// alone that long is indistinguishable from a new one.
+func (l Limit) refillTime() time.Duration {
+	return time.Duration(l.capacity() / l.rate() * float64(time.Second))
+}
+
+// Result describes the outcome of taking a token.
+type Result struct {
+	Allowed bool
+	// Limit is the bucket capacity and Remaining the whole tokens left.
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next token, when not Allowed.
+	RetryAfter time.Duration
+}
+
+// Store keeps buckets. Take refills the bucket for key as of now and
+// removes one token if available; Sweep forgets buckets last touched
+// before the given time.
+type Store interface {
+	Take(ctx context.Context, key string, l Limit, now time.Time) (Result, error)
+	Sweep(ctx context.Context, before time.Time) error
+}
+
+// take applies the token-bucket algorithm to a bucket holding tokens as
+// of last and returns the new token count.
+func take(tokens float64, last time.Time, l Limit, now time.Time) (float64, Result) {
+	capacity, rate := l.capacity(), l.rate()
+	if elapsed := now.Sub(last); elapsed > 0 {
+		tokens = math.Min(capacity, tokens+elapsed.Seconds()*rate)
+	}
+
+	res := Result{Limit: int(capacity)}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	res.Remaining = int(math.Floor(tokens))
+	res.Reset = seconds((capacity - tokens) / rate)
+	return tokens, res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/tracing"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// SQL keeps buckets in the rate_limit_buckets table, so every instance
+// sharing the database enforces one limit per key.
+type SQL struct {
+	db     *sql.DB
+	driver string
+
+	// ForUpdate locks the bucket row while it is updated so concurrent
+	// requests on Postgres cannot both spend the last token. SQLite has
+	// no row locks and serializes writers anyway, so set it for Postgres
+	// only.
+	ForUpdate bool
+}
+
+var _ Store = (*SQL)(nil)
+
+// NewSQL returns a Store for db, a database opened with the database/sql
+// driver named driver ("postgres" or "sqlite"), which is recorded on
+// query spans.
+func NewSQL(db *sql.DB, driver string) *SQL {
+	return &SQL{db: db, driver: driver}
+}
+
+func (s *SQL) Take(ctx context.Context, key string, l Limit, now time.Time) (Result, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	defer tx.Rollback()
+
+	qtx := database.New(tracing.WrapDBTX(tx, s.driver))
+	if err := qtx.EnsureRateLimitBucket(ctx, database.EnsureRateLimitBucketParams{
+		Key:       key,
+		Tokens:    l.capacity(),
+		UpdatedAt: now,
+	}); err != nil {
+		return Result{}, err
+	}
+
+	var b database.RateLimitBucket
+	if s.ForUpdate {
+		b, err = qtx.GetRateLimitBucketForUpdate(ctx, key)
+	} else {
+		b, err = qtx.GetRateLimitBucket(ctx, key)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	tokens, res := take(b.Tokens, b.UpdatedAt, l, now)
+	if err := qtx.UpdateRateLimitBucket(ctx, database.UpdateRateLimitBucketParams{
+		Key:       key,
+		Tokens:    tokens,
+		UpdatedAt: now,
+	}); err != nil {
+		return Result{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Result{}, err
+	}
+	return res, nil
+}
+
+func (s *SQL) Sweep(ctx context.Context, before time.Time) error {
+	_, err := database.New(tracing.WrapDBTX(s.db, s.driver)).DeleteRateLimitBucketsBefore(ctx, before)
+	return err
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"chirpy/internal/logger"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ShutdownOptions control how Serve stops.
+type ShutdownOptions struct {
+	// ShuttingDown is set when shutdown starts, failing readiness.
+	ShuttingDown *atomic.Bool
+	// DrainDelay keeps accepting requests after readiness fails, so load
+	// balancers stop routing here before the listener closes.
+	DrainDelay time.Duration
+	// Timeout bounds how long in-flight requests get to finish before
+	// their connections are closed.
+	Timeout time.Duration
+}
+
+// Serve serves srv on ln until ctx is cancelled, then shuts down
+// gracefully: readiness fails, new connections are refused after
+// DrainDelay, and in-flight requests finish within Timeout. It returns
+// early if the server fails, and returns an error if requests were still
+// running at Timeout.
+func Serve(ctx context.Context, srv *http.Server, ln net.Listener, opts ShutdownOptions) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	logger.Logger.Infow("Shutdown signal received, failing readiness and draining")
+	if opts.ShuttingDown != nil {
+		opts.ShuttingDown.Store(true)
+	}
+	time.Sleep(opts.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Logger.Errorw("Graceful shutdown timed out, closing connections", "error", err)
+		srv.Close()
+		return fmt.Errorf("graceful shutdown: %w", err)
+	}
+	return nil
+}
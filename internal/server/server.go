@@ -0,0 +1,206 @@
+// Package server builds the Chirpy HTTP handler so that main, tests and
+// other programs share one route table.
+package server
+
+import (
+	"chirpy/internal/api"
+	"chirpy/internal/auth"
+	"chirpy/internal/handlers"
+	"chirpy/internal/middleware"
+	"chirpy/internal/ratelimit"
+	"net/http"
+	"strings"
+)
+
+// Middleware wraps an http.Handler.
+type Middleware = middleware.Middleware
+
+type options struct {
+	admin       bool
+	webhooks    bool
+	static      bool
+	metrics     bool
+	compression bool
+	staticDir   string
+	prefix      string
+	cors        *middleware.CORSOptions
+	limiter     *ratelimit.Limiter
+	security    middleware.SecurityOptions
+	middleware  []Middleware
+}
+
+// Option customises the handler returned by New.
+type Option func(*options)
+
+// WithoutAdmin leaves out the /admin routes.
+func WithoutAdmin() Option {
+	return func(o *options) { o.admin = false }
+}
+
+// WithoutWebhooks leaves out the Polka webhook receiver.
+func WithoutWebhooks() Option {
+	return func(o *options) { o.webhooks = false }
+}
+
+// WithoutStatic leaves out the /app/ file server.
+func WithoutStatic() Option {
+	return func(o *options) { o.static = false }
+}
+
+// WithoutMetrics leaves out GET /metrics, for hosts that already expose
+// the default Prometheus registry themselves.
+func WithoutMetrics() Option {
+	return func(o *options) { o.metrics = false }
+}
+
+// WithoutCompression turns off gzip/brotli response compression, for
+// hosts that compress at a proxy or in their own middleware.
+func WithoutCompression() Option {
+	return func(o *options) { o.compression = false }
+}
+
+// WithCORS lets the browser origins in opts call the API.
+func WithCORS(opts middleware.CORSOptions) Option {
+	return func(o *options) { o.cors = &opts }
+}
+
+// WithSecurityHeaders replaces the default security header settings.
+func WithSecurityHeaders(opts middleware.SecurityOptions) Option {
+	return func(o *options) { o.security = opts }
+}
+
+// WithRateLimiter applies l to every route it has a rule for.
+func WithRateLimiter(l *ratelimit.Limiter) Option {
+	return func(o *options) { o.limiter = l }
+}
+
+// WithStaticDir serves /app/ from dir instead of the working directory.
+func WithStaticDir(dir string) Option {
+	return func(o *options) { o.staticDir = dir }
+}
+
+// WithPrefix expects every request path to start with prefix (for
+// example "/chirpy") and strips it before routing, so the handler can be
+// mounted with mux.Handle("/chirpy/", h). Other paths get 404.
+func WithPrefix(prefix string) Option {
+	return func(o *options) { o.prefix = strings.TrimSuffix(prefix, "/") }
+}
+
+// WithMiddleware adds middleware around the router, outermost first. It
+// runs inside the standard chain, after request IDs are assigned and
+// panics are recovered, so it can use logger.FromContext.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(o *options) { o.middleware = append(o.middleware, mw...) }
+}
+
+// New returns the Chirpy API with every route registered and the standard
+// middleware applied to all of them: tracing, request IDs, panic
+// recovery, access logging, security headers, CORS (when configured) and
+// compression. By default every subsystem is enabled.
+func New(cfg *api.Config, opts ...Option) http.Handler {
+	o := options{
+		admin:       true,
+		webhooks:    true,
+		static:      true,
+		metrics:     true,
+		compression: true,
+		staticDir:   ".",
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	mux := http.NewServeMux()
+	// handle registers a route, rate limited when the limiter has a rule
+	// for its pattern.
+	handle := func(pattern string, h http.Handler) {
+		if o.limiter != nil {
+			h = o.limiter.Wrap(pattern, h)
+		}
+		mux.Handle(pattern, h)
+	}
+
+	// Health
+	handle("GET /livez", handlers.HandleLivez())
+	handle("GET /readyz", handlers.HandleReadyz(cfg))
+	// Deprecated: kept for existing probes, same as /readyz.
+	handle("GET /api/healthz", handlers.HandleReadyz(cfg))
+
+	// Static files
+	if o.static {
+		fileServer := http.FileServer(http.Dir(o.staticDir))
+		handle("/app/", http.StripPrefix("/app", fileServer))
+	}
+
+	// Prometheus
+	if o.metrics {
+		handle("GET /metrics", handlers.HandleMetrics(cfg))
+	}
+
+	// Admin: role checks read the caller's role from the database.
+	if o.admin {
+		moderator := handlers.RequireRole(cfg, auth.RoleModerator)
+		admin := handlers.RequireRole(cfg, auth.RoleAdmin)
+
+		handle("POST /admin/reset", admin(handlers.HandleReset(cfg)))
+		handle("PUT /admin/users/{userID}/role", admin(handlers.HandleUpdateUserRole(cfg)))
+		handle("GET /admin/moderation/words", moderator(handlers.HandleListModerationWords(cfg)))
+		handle("POST /admin/moderation/words", moderator(handlers.HandleCreateModerationWord(cfg)))
+		handle("PUT /admin/moderation/words/{wordID}", moderator(handlers.HandleUpdateModerationWord(cfg)))
+		handle("DELETE /admin/moderation/words/{wordID}", moderator(handlers.HandleDeleteModerationWord(cfg)))
+		handle("GET /admin/reports", moderator(handlers.HandleListReports(cfg)))
+		handle("POST /admin/reports/{reportID}/resolve", moderator(handlers.HandleResolveReport(cfg)))
+	}
+
+	// API
+	handle("POST /api/login", handlers.HandleLogin(cfg))
+	handle("POST /api/refresh", handlers.HandleTokenRefresh(cfg))
+	handle("POST /api/revoke", handlers.HandleTokenRevoke(cfg))
+	handle("POST /api/users", handlers.HandleCreateUser(cfg))
+	active := handlers.RequireActive(cfg)
+	handle("PUT /api/users", active(handlers.HandleUpdateUser(cfg)))
+	handle("GET /api/users/me/blocks", handlers.HandleListBlocks(cfg))
+	handle("POST /api/users/me/blocks", active(handlers.HandleCreateBlock(cfg)))
+	handle("DELETE /api/users/me/blocks/{userID}", active(handlers.HandleDeleteBlock(cfg)))
+	handle("GET /api/users/me/mutes", handlers.HandleListMutes(cfg))
+	handle("POST /api/users/me/mutes", active(handlers.HandleCreateMute(cfg)))
+	handle("DELETE /api/users/me/mutes/{userID}", active(handlers.HandleDeleteMute(cfg)))
+	handle("GET /api/notifications", handlers.HandleListNotifications(cfg))
+	handle("POST /api/notifications/read", handlers.HandleMarkNotificationsRead(cfg))
+	handle("POST /api/chirps", active(handlers.HandleCreateChirp(cfg)))
+	handle("GET /api/chirps", handlers.HandleGetAllChirps(cfg))
+	handle("GET /api/chirps/{chirpID}", handlers.HandleGetChirpByID(cfg))
+	handle("DELETE /api/chirps/{chirpID}", active(handlers.HandleDeleteChirp(cfg)))
+	handle("POST /api/chirps/{chirpID}/reports", active(handlers.HandleCreateReport(cfg)))
+	handle("GET /api/stream/chirps", handlers.HandleChirpStream(cfg))
+	handle("GET /api/ws", handlers.HandleChirpSocket(cfg))
+
+	// Webhook
+	if o.webhooks {
+		handle("POST /api/polka/webhooks", handlers.HandlePolkaWebhook(cfg))
+	}
+
+	chain := []Middleware{
+		middleware.Tracing,
+		middleware.RequestID,
+		middleware.Recover,
+		middleware.Logging,
+		middleware.SecurityHeaders(o.security),
+	}
+	if o.cors != nil {
+		chain = append(chain, middleware.CORS(*o.cors))
+	}
+	if o.compression {
+		chain = append(chain, middleware.Compress(middleware.CompressOptions{}))
+	}
+	chain = append(chain, o.middleware...)
+	// Metrics and Route wrap the mux directly so they see r.Pattern.
+	chain = append(chain, middleware.Metrics, middleware.Route)
+
+	h := middleware.Chain(chain...)(mux)
+
+	if o.prefix != "" {
+		h = http.StripPrefix(o.prefix, h)
+	}
+	return h
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Errors returned by the in-memory store for constraint violations.
+var (
+	ErrDuplicateKey = errors.New("store: duplicate key")
+	ErrForeignKey   = errors.New("store: foreign key violation")
+)
+
+// IsUniqueViolation reports whether err is a unique or primary key
+// violation from any Store implementation.
+func IsUniqueViolation(err error) bool {
+	if errors.Is(err, ErrDuplicateKey) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	var liteErr *sqlite.Error
+	if errors.As(err, &liteErr) {
+		return liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE ||
+			liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return false
+}
+
+// IsForeignKeyViolation reports whether err is a foreign key violation
+// from any Store implementation.
+func IsForeignKeyViolation(err error) bool {
+	if errors.Is(err, ErrForeignKey) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23503"
+	}
+	var liteErr *sqlite.Error
+	if errors.As(err, &liteErr) {
+		return liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY
+	}
+	return false
+}
@@ -0,0 +1,1072 @@
+package store
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Memory is a thread-safe Store that keeps everything in process memory.
+// It is meant for tests and local demos; nothing survives a restart and
+// outbox events are recorded but never relayed.
+type Memory struct {
+	mu    sync.RWMutex
+	state *memState
+}
+
+var _ Store = (*Memory)(nil)
+
+// NewMemory returns an empty in-memory Store.
+func NewMemory() *Memory {
+	return &Memory{state: newMemState()}
+}
+
+// OutboxEvents returns the events recorded so far, oldest first.
+func (m *Memory) OutboxEvents() []database.OutboxEvent {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.state.outbox)
+}
+
+// InTx holds the write lock for the duration of fn and logs how to undo
+// each write it makes, replaying the log backwards if fn fails or panics.
+// A transaction costs only as much as the writes it makes.
+func (m *Memory) InTx(ctx context.Context, fn func(Store) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.state
+	s.undo = []func(){}
+	committed := false
+	defer func() {
+		if !committed {
+			for i := len(s.undo) - 1; i >= 0; i-- {
+				s.undo[i]()
+			}
+		}
+		s.undo = nil
+	}()
+	if err := fn(s); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+func (m *Memory) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.CreateUser(ctx, arg)
+}
+
+func (m *Memory) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.GetUserByEmail(ctx, email)
+}
+
+func (m *Memory) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.GetUserByID(ctx, id)
+}
+
+func (m *Memory) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.UpdateUser(ctx, arg)
+}
+
+func (m *Memory) UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.UpgradeToChirpyRed(ctx, id)
+}
+
+func (m *Memory) SuspendUser(ctx context.Context, arg database.SuspendUserParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.SuspendUser(ctx, arg)
+}
+
+func (m *Memory) UpdateUserRole(ctx context.Context, arg database.UpdateUserRoleParams) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.UpdateUserRole(ctx, arg)
+}
+
+func (m *Memory) DeleteAllUsers(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.DeleteAllUsers(ctx)
+}
+
+func (m *Memory) CreateChirps(ctx context.Context, arg database.CreateChirpsParams) (database.Chirp, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.CreateChirps(ctx, arg)
+}
+
+func (m *Memory) GetAllChirps(ctx context.Context) ([]database.Chirp, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.GetAllChirps(ctx)
+}
+
+func (m *Memory) GetChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.GetChirpsByAuthor(ctx, userID)
+}
+
+func (m *Memory) GetChirpByID(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.GetChirpByID(ctx, id)
+}
+
+func (m *Memory) HideChirp(ctx context.Context, arg database.HideChirpParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.HideChirp(ctx, arg)
+}
+
+func (m *Memory) DeleteChirp(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.DeleteChirp(ctx, id)
+}
+
+func (m *Memory) DeleteAllChirps(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.DeleteAllChirps(ctx)
+}
+
+func (m *Memory) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.CreateRefreshToken(ctx, arg)
+}
+
+func (m *Memory) GetRefreshToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.GetRefreshToken(ctx, token)
+}
+
+func (m *Memory) RevokeRefreshToken(ctx context.Context, arg database.RevokeRefreshTokenParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.RevokeRefreshToken(ctx, arg)
+}
+
+func (m *Memory) RevokeAllRefreshTokensForUser(ctx context.Context, arg database.RevokeAllRefreshTokensForUserParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.RevokeAllRefreshTokensForUser(ctx, arg)
+}
+
+func (m *Memory) CreateBlock(ctx context.Context, arg database.CreateBlockParams) (database.Block, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.CreateBlock(ctx, arg)
+}
+
+func (m *Memory) DeleteBlock(ctx context.Context, arg database.DeleteBlockParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.DeleteBlock(ctx, arg)
+}
+
+func (m *Memory) GetBlock(ctx context.Context, arg database.GetBlockParams) (database.Block, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.GetBlock(ctx, arg)
+}
+
+func (m *Memory) ListBlocks(ctx context.Context, blockerID uuid.UUID) ([]database.Block, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.ListBlocks(ctx, blockerID)
+}
+
+func (m *Memory) CreateMute(ctx context.Context, arg database.CreateMuteParams) (database.Mute, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.CreateMute(ctx, arg)
+}
+
+func (m *Memory) DeleteMute(ctx context.Context, arg database.DeleteMuteParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.DeleteMute(ctx, arg)
+}
+
+func (m *Memory) ListMutes(ctx context.Context, muterID uuid.UUID) ([]database.Mute, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.ListMutes(ctx, muterID)
+}
+
+func (m *Memory) ListHiddenAuthors(ctx context.Context, arg database.ListHiddenAuthorsParams) ([]database.ListHiddenAuthorsRow, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.ListHiddenAuthors(ctx, arg)
+}
+
+func (m *Memory) CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.CreateNotification(ctx, arg)
+}
+
+func (m *Memory) GetUnreadNotificationGroup(ctx context.Context, arg database.GetUnreadNotificationGroupParams) (database.Notification, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.GetUnreadNotificationGroup(ctx, arg)
+}
+
+func (m *Memory) AddNotificationActor(ctx context.Context, arg database.AddNotificationActorParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.AddNotificationActor(ctx, arg)
+}
+
+func (m *Memory) BumpNotification(ctx context.Context, arg database.BumpNotificationParams) (database.Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.BumpNotification(ctx, arg)
+}
+
+func (m *Memory) ListNotifications(ctx context.Context, arg database.ListNotificationsParams) ([]database.Notification, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.ListNotifications(ctx, arg)
+}
+
+func (m *Memory) ListUnreadNotifications(ctx context.Context, arg database.ListUnreadNotificationsParams) ([]database.Notification, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.ListUnreadNotifications(ctx, arg)
+}
+
+func (m *Memory) CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.CountUnreadNotifications(ctx, userID)
+}
+
+func (m *Memory) MarkNotificationRead(ctx context.Context, arg database.MarkNotificationReadParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.MarkNotificationRead(ctx, arg)
+}
+
+func (m *Memory) MarkAllNotificationsRead(ctx context.Context, arg database.MarkAllNotificationsReadParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.MarkAllNotificationsRead(ctx, arg)
+}
+
+func (m *Memory) CreateReport(ctx context.Context, arg database.CreateReportParams) (database.Report, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.CreateReport(ctx, arg)
+}
+
+func (m *Memory) GetReport(ctx context.Context, id uuid.UUID) (database.Report, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.GetReport(ctx, id)
+}
+
+func (m *Memory) ListReports(ctx context.Context, status string) ([]database.ListReportsRow, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.ListReports(ctx, status)
+}
+
+func (m *Memory) ResolveReport(ctx context.Context, arg database.ResolveReportParams) (database.Report, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.ResolveReport(ctx, arg)
+}
+
+func (m *Memory) ResolveOpenReportsForChirp(ctx context.Context, arg database.ResolveOpenReportsForChirpParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.ResolveOpenReportsForChirp(ctx, arg)
+}
+
+func (m *Memory) CreateModerationWord(ctx context.Context, arg database.CreateModerationWordParams) (database.ModerationWord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.CreateModerationWord(ctx, arg)
+}
+
+func (m *Memory) ListModerationWords(ctx context.Context) ([]database.ModerationWord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.ListModerationWords(ctx)
+}
+
+func (m *Memory) UpdateModerationWord(ctx context.Context, arg database.UpdateModerationWordParams) (database.ModerationWord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.UpdateModerationWord(ctx, arg)
+}
+
+func (m *Memory) DeleteModerationWord(ctx context.Context, id uuid.UUID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.DeleteModerationWord(ctx, id)
+}
+
+func (m *Memory) InsertOutboxEvent(ctx context.Context, arg database.InsertOutboxEventParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.InsertOutboxEvent(ctx, arg)
+}
+
+// memState holds the data and implements Store without locking; Memory
+// guards it. Inside InTx it is handed to fn directly.
+type memState struct {
+	users              map[uuid.UUID]database.User
+	chirps             map[uuid.UUID]database.Chirp
+	refreshTokens      map[string]database.RefreshToken
+	reports            map[uuid.UUID]database.Report
+	words              map[uuid.UUID]database.ModerationWord
+	blocks             map[userPair]database.Block
+	mutes              map[userPair]database.Mute
+	notifications      map[uuid.UUID]database.Notification
+	notificationActors map[userPair]database.NotificationActor
+	outbox             []database.OutboxEvent
+
+	// undo reverses the writes made so far by the running transaction,
+	// in reverse order; nil outside InTx.
+	undo []func()
+}
+
+func newMemState() *memState {
+	return &memState{
+		users:              make(map[uuid.UUID]database.User),
+		chirps:             make(map[uuid.UUID]database.Chirp),
+		refreshTokens:      make(map[string]database.RefreshToken),
+		reports:            make(map[uuid.UUID]database.Report),
+		words:              make(map[uuid.UUID]database.ModerationWord),
+		blocks:             make(map[userPair]database.Block),
+		mutes:              make(map[userPair]database.Mute),
+		notifications:      make(map[uuid.UUID]database.Notification),
+		notificationActors: make(map[userPair]database.NotificationActor),
+	}
+}
+
+// setKey sets m[k] to v, logging how to undo it inside InTx.
+func setKey[K comparable, V any](s *memState, m map[K]V, k K, v V) {
+	if s.undo != nil {
+		if old, ok := m[k]; ok {
+			s.undo = append(s.undo, func() { m[k] = old })
+		} else {
+			s.undo = append(s.undo, func() { delete(m, k) })
+		}
+	}
+	m[k] = v
+}
+
+// deleteKey deletes m[k], logging how to undo it inside InTx.
+func deleteKey[K comparable, V any](s *memState, m map[K]V, k K) {
+	old, ok := m[k]
+	if !ok {
+		return
+	}
+	if s.undo != nil {
+		s.undo = append(s.undo, func() { m[k] = old })
+	}
+	delete(m, k)
+}
+
+func (s *memState) InTx(ctx context.Context, fn func(Store) error) error {
+	return fn(s)
+}
+
+func (s *memState) emailTaken(email string, except uuid.UUID) bool {
+	for _, u := range s.users {
+		if u.Email == email && u.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *memState) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	if _, ok := s.users[arg.ID]; ok || s.emailTaken(arg.Email, uuid.Nil) {
+		return database.User{}, ErrDuplicateKey
+	}
+	u := database.User{
+		ID:             arg.ID,
+		CreatedAt:      arg.CreatedAt,
+		UpdatedAt:      arg.UpdatedAt,
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		Role:           "user",
+	}
+	setKey(s, s.users, u.ID, u)
+	return u, nil
+}
+
+func (s *memState) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	for _, u := range s.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *memState) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	u, ok := s.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return u, nil
+}
+
+func (s *memState) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	u, ok := s.users[arg.ID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	if s.emailTaken(arg.Email, arg.ID) {
+		return database.User{}, ErrDuplicateKey
+	}
+	u.Email = arg.Email
+	u.HashedPassword = arg.HashedPassword
+	u.UpdatedAt = arg.UpdatedAt
+	setKey(s, s.users, u.ID, u)
+	return u, nil
+}
+
+func (s *memState) UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (int64, error) {
+	u, ok := s.users[id]
+	if !ok {
+		return 0, nil
+	}
+	u.IsChirpyRed = true
+	setKey(s, s.users, id, u)
+	return 1, nil
+}
+
+func (s *memState) SuspendUser(ctx context.Context, arg database.SuspendUserParams) (int64, error) {
+	u, ok := s.users[arg.ID]
+	if !ok {
+		return 0, nil
+	}
+	u.SuspendedUntil = arg.SuspendedUntil
+	u.UpdatedAt = arg.UpdatedAt
+	setKey(s, s.users, arg.ID, u)
+	return 1, nil
+}
+
+func (s *memState) UpdateUserRole(ctx context.Context, arg database.UpdateUserRoleParams) (database.User, error) {
+	u, ok := s.users[arg.ID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	u.Role = arg.Role
+	u.UpdatedAt = arg.UpdatedAt
+	setKey(s, s.users, arg.ID, u)
+	return u, nil
+}
+
+// DeleteAllUsers cascades to chirps, refresh tokens, reports, blocks,
+// mutes and notifications like the foreign keys.
+func (s *memState) DeleteAllUsers(ctx context.Context) error {
+	clear(s.users)
+	clear(s.chirps)
+	clear(s.refreshTokens)
+	clear(s.reports)
+	clear(s.blocks)
+	clear(s.mutes)
+	clear(s.notifications)
+	clear(s.notificationActors)
+	return nil
+}
+
+func (s *memState) CreateChirps(ctx context.Context, arg database.CreateChirpsParams) (database.Chirp, error) {
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.Chirp{}, ErrForeignKey
+	}
+	if _, ok := s.chirps[arg.ID]; ok {
+		return database.Chirp{}, ErrDuplicateKey
+	}
+	c := database.Chirp{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Body:      arg.Body,
+		UserID:    arg.UserID,
+	}
+	setKey(s, s.chirps, c.ID, c)
+	return c, nil
+}
+
+func (s *memState) GetAllChirps(ctx context.Context) ([]database.Chirp, error) {
+	return s.sortedChirps(func(database.Chirp) bool { return true }), nil
+}
+
+func (s *memState) GetChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	return s.sortedChirps(func(c database.Chirp) bool { return c.UserID == userID }), nil
+}
+
+// sortedChirps returns matching chirps oldest first so results are stable.
+func (s *memState) sortedChirps(match func(database.Chirp) bool) []database.Chirp {
+	var out []database.Chirp
+	for _, c := range s.chirps {
+		if match(c) {
+			out = append(out, c)
+		}
+	}
+	slices.SortFunc(out, func(a, b database.Chirp) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return slices.Compare(a.ID[:], b.ID[:])
+	})
+	return out
+}
+
+func (s *memState) GetChirpByID(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	c, ok := s.chirps[id]
+	if !ok {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return c, nil
+}
+
+func (s *memState) HideChirp(ctx context.Context, arg database.HideChirpParams) (int64, error) {
+	c, ok := s.chirps[arg.ID]
+	if !ok {
+		return 0, nil
+	}
+	c.HiddenAt = arg.HiddenAt
+	c.UpdatedAt = arg.UpdatedAt
+	setKey(s, s.chirps, arg.ID, c)
+	return 1, nil
+}
+
+// DeleteChirp cascades to the chirp's reports.
+func (s *memState) DeleteChirp(ctx context.Context, id uuid.UUID) error {
+	deleteKey(s, s.chirps, id)
+	for rid, r := range s.reports {
+		if r.ChirpID == id {
+			deleteKey(s, s.reports, rid)
+		}
+	}
+	s.deleteNotifications(func(n database.Notification) bool {
+		return n.ChirpID.Valid && n.ChirpID.UUID == id
+	})
+	return nil
+}
+
+func (s *memState) DeleteAllChirps(ctx context.Context) error {
+	clear(s.chirps)
+	clear(s.reports)
+	s.deleteNotifications(func(n database.Notification) bool { return n.ChirpID.Valid })
+	return nil
+}
+
+func (s *memState) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) error {
+	if _, ok := s.users[arg.UserID]; !ok {
+		return ErrForeignKey
+	}
+	if _, ok := s.refreshTokens[arg.Token]; ok {
+		return ErrDuplicateKey
+	}
+	setKey(s, s.refreshTokens, arg.Token, database.RefreshToken{
+		Token:     arg.Token,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+	})
+	return nil
+}
+
+func (s *memState) GetRefreshToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	rt, ok := s.refreshTokens[token]
+	if !ok {
+		return database.RefreshToken{}, sql.ErrNoRows
+	}
+	return rt, nil
+}
+
+func (s *memState) RevokeRefreshToken(ctx context.Context, arg database.RevokeRefreshTokenParams) error {
+	if rt, ok := s.refreshTokens[arg.Token]; ok {
+		rt.RevokedAt = arg.RevokedAt
+		rt.UpdatedAt = arg.UpdatedAt
+		setKey(s, s.refreshTokens, arg.Token, rt)
+	}
+	return nil
+}
+
+func (s *memState) RevokeAllRefreshTokensForUser(ctx context.Context, arg database.RevokeAllRefreshTokensForUserParams) (int64, error) {
+	var n int64
+	for token, rt := range s.refreshTokens {
+		if rt.UserID == arg.UserID && !rt.RevokedAt.Valid {
+			rt.RevokedAt = arg.RevokedAt
+			rt.UpdatedAt = arg.UpdatedAt
+			setKey(s, s.refreshTokens, token, rt)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// userPair keys the blocks, mutes and notification actor maps:
+// (blocker, blocked), (muter, muted) or (notification, actor).
+type userPair struct{ from, to uuid.UUID }
+
+func (s *memState) CreateBlock(ctx context.Context, arg database.CreateBlockParams) (database.Block, error) {
+	if !s.usersExist(arg.BlockerID, arg.BlockedID) {
+		return database.Block{}, ErrForeignKey
+	}
+	key := userPair{arg.BlockerID, arg.BlockedID}
+	if _, ok := s.blocks[key]; ok {
+		return database.Block{}, ErrDuplicateKey
+	}
+	b := database.Block{BlockerID: arg.BlockerID, BlockedID: arg.BlockedID, CreatedAt: arg.CreatedAt}
+	setKey(s, s.blocks, key, b)
+	return b, nil
+}
+
+func (s *memState) DeleteBlock(ctx context.Context, arg database.DeleteBlockParams) (int64, error) {
+	key := userPair{arg.BlockerID, arg.BlockedID}
+	if _, ok := s.blocks[key]; !ok {
+		return 0, nil
+	}
+	deleteKey(s, s.blocks, key)
+	return 1, nil
+}
+
+func (s *memState) GetBlock(ctx context.Context, arg database.GetBlockParams) (database.Block, error) {
+	b, ok := s.blocks[userPair{arg.BlockerID, arg.BlockedID}]
+	if !ok {
+		return database.Block{}, sql.ErrNoRows
+	}
+	return b, nil
+}
+
+func (s *memState) ListBlocks(ctx context.Context, blockerID uuid.UUID) ([]database.Block, error) {
+	var out []database.Block
+	for _, b := range s.blocks {
+		if b.BlockerID == blockerID {
+			out = append(out, b)
+		}
+	}
+	slices.SortFunc(out, func(a, b database.Block) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.BlockedID.String(), b.BlockedID.String())
+	})
+	return out, nil
+}
+
+func (s *memState) CreateMute(ctx context.Context, arg database.CreateMuteParams) (database.Mute, error) {
+	if !s.usersExist(arg.MuterID, arg.MutedID) {
+		return database.Mute{}, ErrForeignKey
+	}
+	key := userPair{arg.MuterID, arg.MutedID}
+	if _, ok := s.mutes[key]; ok {
+		return database.Mute{}, ErrDuplicateKey
+	}
+	m := database.Mute{MuterID: arg.MuterID, MutedID: arg.MutedID, CreatedAt: arg.CreatedAt}
+	setKey(s, s.mutes, key, m)
+	return m, nil
+}
+
+func (s *memState) DeleteMute(ctx context.Context, arg database.DeleteMuteParams) (int64, error) {
+	key := userPair{arg.MuterID, arg.MutedID}
+	if _, ok := s.mutes[key]; !ok {
+		return 0, nil
+	}
+	deleteKey(s, s.mutes, key)
+	return 1, nil
+}
+
+func (s *memState) ListMutes(ctx context.Context, muterID uuid.UUID) ([]database.Mute, error) {
+	var out []database.Mute
+	for _, m := range s.mutes {
+		if m.MuterID == muterID {
+			out = append(out, m)
+		}
+	}
+	slices.SortFunc(out, func(a, b database.Mute) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.MutedID.String(), b.MutedID.String())
+	})
+	return out, nil
+}
+
+func (s *memState) ListHiddenAuthors(ctx context.Context, arg database.ListHiddenAuthorsParams) ([]database.ListHiddenAuthorsRow, error) {
+	var out []database.ListHiddenAuthorsRow
+	for key := range s.blocks {
+		if key.from == arg.BlockerID {
+			out = append(out, database.ListHiddenAuthorsRow{UserID: key.to, Kind: "block"})
+		}
+		if key.to == arg.BlockedID {
+			out = append(out, database.ListHiddenAuthorsRow{UserID: key.from, Kind: "block"})
+		}
+	}
+	for key := range s.mutes {
+		if key.from == arg.MuterID {
+			out = append(out, database.ListHiddenAuthorsRow{UserID: key.to, Kind: "mute"})
+		}
+	}
+	return out, nil
+}
+
+func (s *memState) usersExist(ids ...uuid.UUID) bool {
+	for _, id := range ids {
+		if _, ok := s.users[id]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *memState) CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.Notification, error) {
+	if !s.usersExist(arg.UserID, arg.LastActorID) {
+		return database.Notification{}, ErrForeignKey
+	}
+	if _, ok := s.chirps[arg.ChirpID.UUID]; arg.ChirpID.Valid && !ok {
+		return database.Notification{}, ErrForeignKey
+	}
+	// ON CONFLICT DO NOTHING, on the primary key or an unread group.
+	if _, ok := s.notifications[arg.ID]; ok {
+		return database.Notification{}, sql.ErrNoRows
+	}
+	_, err := s.GetUnreadNotificationGroup(ctx, database.GetUnreadNotificationGroupParams{
+		UserID: arg.UserID, Type: arg.Type, ChirpID: arg.ChirpID,
+	})
+	if err == nil {
+		return database.Notification{}, sql.ErrNoRows
+	}
+	n := database.Notification{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		UserID:      arg.UserID,
+		Type:        arg.Type,
+		ChirpID:     arg.ChirpID,
+		LastActorID: arg.LastActorID,
+		ActorCount:  1,
+	}
+	setKey(s, s.notifications, n.ID, n)
+	return n, nil
+}
+
+func (s *memState) GetUnreadNotificationGroup(ctx context.Context, arg database.GetUnreadNotificationGroupParams) (database.Notification, error) {
+	var (
+		found database.Notification
+		ok    bool
+	)
+	for _, n := range s.notifications {
+		if n.UserID != arg.UserID || n.Type != arg.Type || n.ChirpID != arg.ChirpID || n.ReadAt.Valid {
+			continue
+		}
+		if !ok || n.UpdatedAt.After(found.UpdatedAt) {
+			found, ok = n, true
+		}
+	}
+	if !ok {
+		return database.Notification{}, sql.ErrNoRows
+	}
+	return found, nil
+}
+
+func (s *memState) AddNotificationActor(ctx context.Context, arg database.AddNotificationActorParams) (int64, error) {
+	if _, ok := s.notifications[arg.NotificationID]; !ok || !s.usersExist(arg.ActorID) {
+		return 0, ErrForeignKey
+	}
+	key := userPair{arg.NotificationID, arg.ActorID}
+	if _, ok := s.notificationActors[key]; ok {
+		return 0, nil
+	}
+	setKey(s, s.notificationActors, key, database.NotificationActor{
+		NotificationID: arg.NotificationID,
+		ActorID:        arg.ActorID,
+		CreatedAt:      arg.CreatedAt,
+	})
+	return 1, nil
+}
+
+func (s *memState) BumpNotification(ctx context.Context, arg database.BumpNotificationParams) (database.Notification, error) {
+	n, ok := s.notifications[arg.ID]
+	if !ok {
+		return database.Notification{}, sql.ErrNoRows
+	}
+	if !s.usersExist(arg.LastActorID) {
+		return database.Notification{}, ErrForeignKey
+	}
+	n.ActorCount++
+	n.LastActorID = arg.LastActorID
+	n.UpdatedAt = arg.UpdatedAt
+	setKey(s, s.notifications, n.ID, n)
+	return n, nil
+}
+
+func (s *memState) ListNotifications(ctx context.Context, arg database.ListNotificationsParams) ([]database.Notification, error) {
+	return s.notificationPage(arg, false), nil
+}
+
+func (s *memState) ListUnreadNotifications(ctx context.Context, arg database.ListUnreadNotificationsParams) ([]database.Notification, error) {
+	return s.notificationPage(database.ListNotificationsParams(arg), true), nil
+}
+
+// notificationPage returns arg.UserID's notifications newest first,
+// after the (updated_at, id) cursor, like the SQL queries.
+func (s *memState) notificationPage(arg database.ListNotificationsParams, unreadOnly bool) []database.Notification {
+	var out []database.Notification
+	for _, n := range s.notifications {
+		if n.UserID != arg.UserID || (unreadOnly && n.ReadAt.Valid) {
+			continue
+		}
+		if n.UpdatedAt.Before(arg.BeforeAt) ||
+			(n.UpdatedAt.Equal(arg.TieAt) && slices.Compare(n.ID[:], arg.BeforeID[:]) < 0) {
+			out = append(out, n)
+		}
+	}
+	slices.SortFunc(out, func(a, b database.Notification) int {
+		if c := b.UpdatedAt.Compare(a.UpdatedAt); c != 0 {
+			return c
+		}
+		return slices.Compare(b.ID[:], a.ID[:])
+	})
+	if n := int(arg.PageSize); len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+func (s *memState) CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var n int64
+	for _, notification := range s.notifications {
+		if notification.UserID == userID && !notification.ReadAt.Valid {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memState) MarkNotificationRead(ctx context.Context, arg database.MarkNotificationReadParams) (int64, error) {
+	n, ok := s.notifications[arg.ID]
+	if !ok || n.UserID != arg.UserID || n.ReadAt.Valid {
+		return 0, nil
+	}
+	n.ReadAt = arg.ReadAt
+	setKey(s, s.notifications, n.ID, n)
+	return 1, nil
+}
+
+func (s *memState) MarkAllNotificationsRead(ctx context.Context, arg database.MarkAllNotificationsReadParams) (int64, error) {
+	var count int64
+	for id, n := range s.notifications {
+		if n.UserID == arg.UserID && !n.ReadAt.Valid {
+			n.ReadAt = arg.ReadAt
+			setKey(s, s.notifications, id, n)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// deleteNotifications removes the matching notifications and their
+// actors, as the foreign keys cascade.
+func (s *memState) deleteNotifications(match func(database.Notification) bool) {
+	for id, n := range s.notifications {
+		if match(n) {
+			deleteKey(s, s.notifications, id)
+		}
+	}
+	for key := range s.notificationActors {
+		if _, ok := s.notifications[key.from]; !ok {
+			deleteKey(s, s.notificationActors, key)
+		}
+	}
+}
+
+func (s *memState) CreateReport(ctx context.Context, arg database.CreateReportParams) (database.Report, error) {
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return database.Report{}, ErrForeignKey
+	}
+	if _, ok := s.users[arg.ReporterID]; !ok {
+		return database.Report{}, ErrForeignKey
+	}
+	for _, r := range s.reports {
+		if r.ID == arg.ID || (r.ChirpID == arg.ChirpID && r.ReporterID == arg.ReporterID) {
+			return database.Report{}, ErrDuplicateKey
+		}
+	}
+	r := database.Report{
+		ID:         arg.ID,
+		CreatedAt:  arg.CreatedAt,
+		UpdatedAt:  arg.UpdatedAt,
+		ChirpID:    arg.ChirpID,
+		ReporterID: arg.ReporterID,
+		Reason:     arg.Reason,
+		Status:     "open",
+	}
+	setKey(s, s.reports, r.ID, r)
+	return r, nil
+}
+
+func (s *memState) GetReport(ctx context.Context, id uuid.UUID) (database.Report, error) {
+	r, ok := s.reports[id]
+	if !ok {
+		return database.Report{}, sql.ErrNoRows
+	}
+	return r, nil
+}
+
+func (s *memState) ListReports(ctx context.Context, status string) ([]database.ListReportsRow, error) {
+	var out []database.ListReportsRow
+	for _, r := range s.reports {
+		if r.Status != status {
+			continue
+		}
+		c := s.chirps[r.ChirpID]
+		out = append(out, database.ListReportsRow{
+			ID:             r.ID,
+			CreatedAt:      r.CreatedAt,
+			UpdatedAt:      r.UpdatedAt,
+			ChirpID:        r.ChirpID,
+			ReporterID:     r.ReporterID,
+			Reason:         r.Reason,
+			Status:         r.Status,
+			Action:         r.Action,
+			ResolvedAt:     r.ResolvedAt,
+			ChirpBody:      c.Body,
+			ChirpUserID:    c.UserID,
+			ChirpCreatedAt: c.CreatedAt,
+			ChirpUpdatedAt: c.UpdatedAt,
+			ChirpHiddenAt:  c.HiddenAt,
+		})
+	}
+	slices.SortFunc(out, func(a, b database.ListReportsRow) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return slices.Compare(a.ID[:], b.ID[:])
+	})
+	return out, nil
+}
+
+func (s *memState) ResolveReport(ctx context.Context, arg database.ResolveReportParams) (database.Report, error) {
+	r, ok := s.reports[arg.ID]
+	if !ok || r.Status != "open" {
+		return database.Report{}, sql.ErrNoRows
+	}
+	r.Status = "resolved"
+	r.Action = arg.Action
+	r.ResolvedAt = arg.ResolvedAt
+	r.UpdatedAt = arg.UpdatedAt
+	setKey(s, s.reports, r.ID, r)
+	return r, nil
+}
+
+func (s *memState) ResolveOpenReportsForChirp(ctx context.Context, arg database.ResolveOpenReportsForChirpParams) (int64, error) {
+	var n int64
+	for id, r := range s.reports {
+		if r.ChirpID == arg.ChirpID && r.Status == "open" {
+			r.Status = "resolved"
+			r.Action = arg.Action
+			r.ResolvedAt = arg.ResolvedAt
+			r.UpdatedAt = arg.UpdatedAt
+			setKey(s, s.reports, id, r)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memState) wordTaken(word string, except uuid.UUID) bool {
+	for _, w := range s.words {
+		if w.Word == word && w.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *memState) CreateModerationWord(ctx context.Context, arg database.CreateModerationWordParams) (database.ModerationWord, error) {
+	if _, ok := s.words[arg.ID]; ok || s.wordTaken(arg.Word, uuid.Nil) {
+		return database.ModerationWord{}, ErrDuplicateKey
+	}
+	w := database.ModerationWord{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		Word:      arg.Word,
+		Severity:  arg.Severity,
+	}
+	setKey(s, s.words, w.ID, w)
+	return w, nil
+}
+
+func (s *memState) ListModerationWords(ctx context.Context) ([]database.ModerationWord, error) {
+	var out []database.ModerationWord
+	for _, w := range s.words {
+		out = append(out, w)
+	}
+	slices.SortFunc(out, func(a, b database.ModerationWord) int {
+		return strings.Compare(a.Word, b.Word)
+	})
+	return out, nil
+}
+
+func (s *memState) UpdateModerationWord(ctx context.Context, arg database.UpdateModerationWordParams) (database.ModerationWord, error) {
+	w, ok := s.words[arg.ID]
+	if !ok {
+		return database.ModerationWord{}, sql.ErrNoRows
+	}
+	if s.wordTaken(arg.Word, arg.ID) {
+		return database.ModerationWord{}, ErrDuplicateKey
+	}
+	w.Word = arg.Word
+	w.Severity = arg.Severity
+	w.UpdatedAt = arg.UpdatedAt
+	setKey(s, s.words, w.ID, w)
+	return w, nil
+}
+
+func (s *memState) DeleteModerationWord(ctx context.Context, id uuid.UUID) (int64, error) {
+	if _, ok := s.words[id]; !ok {
+		return 0, nil
+	}
+	deleteKey(s, s.words, id)
+	return 1, nil
+}
+
+func (s *memState) InsertOutboxEvent(ctx context.Context, arg database.InsertOutboxEventParams) error {
+	if s.undo != nil {
+		n := len(s.outbox)
+		s.undo = append(s.undo, func() { s.outbox = s.outbox[:n] })
+	}
+	s.outbox = append(s.outbox, database.OutboxEvent{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		EventType:   arg.EventType,
+		AggregateID: arg.AggregateID,
+		Payload:     arg.Payload,
+	})
+	return nil
+}
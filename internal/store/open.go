@@ -0,0 +1,56 @@
+package store
+
+import (
+	"chirpy/internal/config"
+	"chirpy/internal/tracing"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// sqlitePragmas are applied to every SQLite connection: enforce foreign
+// keys like Postgres does, wait on a locked database instead of failing,
+// allow readers alongside the writer, and take the write lock when a
+// transaction begins so two transactions cannot deadlock upgrading.
+const sqlitePragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+
+// Open opens a database/sql handle for driver, as returned by
+// config.DBConfig.Driver. For SQLite the URL is
+// "sqlite://PATH" (relative) or "sqlite:///PATH" (absolute), optionally
+// followed by driver query parameters.
+func Open(driver, url string) (*sql.DB, error) {
+	switch driver {
+	case config.DriverPostgres:
+		return tracing.OpenDB("postgres", url)
+	case config.DriverSQLite:
+		dsn := SQLiteDSN(url)
+		db, err := tracing.OpenDB("sqlite", dsn)
+		if err != nil {
+			return nil, err
+		}
+		// Every connection to an in-memory database is a separate empty
+		// database, so keep exactly one.
+		if strings.Contains(dsn, ":memory:") || strings.Contains(dsn, "mode=memory") {
+			db.SetMaxOpenConns(1)
+			db.SetConnMaxLifetime(0)
+			db.SetConnMaxIdleTime(0)
+		}
+		return db, nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+}
+
+// SQLiteDSN converts a sqlite:// URL into a DSN for modernc.org/sqlite.
+func SQLiteDSN(url string) string {
+	dsn := strings.TrimPrefix(url, config.DriverSQLite+":")
+	dsn = strings.TrimPrefix(dsn, "//")
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + sqlitePragmas
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/tracing"
+	"context"
+	"database/sql"
+)
+
+// SQL is the Store backed by the sqlc queries. The queries avoid
+// engine-specific functions, so it serves both Postgres and SQLite.
+type SQL struct {
+	*database.Queries
+	db     *sql.DB // nil inside a transaction
+	driver string
+}
+
+var _ Store = (*SQL)(nil)
+
+// NewSQL returns a Store that traces every query it runs on db, a
+// database opened with the database/sql driver named driver ("postgres"
+// or "sqlite").
+func NewSQL(db *sql.DB, driver string) *SQL {
+	return &SQL{
+		Queries: database.New(tracing.WrapDBTX(db, driver)),
+		db:      db,
+		driver:  driver,
+	}
+}
+
+func (s *SQL) InTx(ctx context.Context, fn func(Store) error) error {
+	if s.db == nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&SQL{Queries: database.New(tracing.WrapDBTX(tx, s.driver)), driver: s.driver}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,82 @@
+// Package store defines the persistence operations used by the HTTP
+// handlers, independent of the database behind them.
+package store
+
+import (
+	"chirpy/internal/database"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Store is implemented by SQL and Memory. Missing rows are reported as
+// sql.ErrNoRows, as sqlc does; use IsUniqueViolation and
+// IsForeignKeyViolation to classify constraint errors.
+type Store interface {
+	// Users
+	CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error)
+	GetUserByEmail(ctx context.Context, email string) (database.User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error)
+	UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error)
+	UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (int64, error)
+	SuspendUser(ctx context.Context, arg database.SuspendUserParams) (int64, error)
+	UpdateUserRole(ctx context.Context, arg database.UpdateUserRoleParams) (database.User, error)
+	DeleteAllUsers(ctx context.Context) error
+
+	// Chirps
+	CreateChirps(ctx context.Context, arg database.CreateChirpsParams) (database.Chirp, error)
+	GetAllChirps(ctx context.Context) ([]database.Chirp, error)
+	GetChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error)
+	GetChirpByID(ctx context.Context, id uuid.UUID) (database.Chirp, error)
+	HideChirp(ctx context.Context, arg database.HideChirpParams) (int64, error)
+	DeleteChirp(ctx context.Context, id uuid.UUID) error
+	DeleteAllChirps(ctx context.Context) error
+
+	// Refresh tokens
+	CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) error
+	GetRefreshToken(ctx context.Context, token string) (database.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, arg database.RevokeRefreshTokenParams) error
+	RevokeAllRefreshTokensForUser(ctx context.Context, arg database.RevokeAllRefreshTokensForUserParams) (int64, error)
+
+	// Blocks and mutes
+	CreateBlock(ctx context.Context, arg database.CreateBlockParams) (database.Block, error)
+	DeleteBlock(ctx context.Context, arg database.DeleteBlockParams) (int64, error)
+	GetBlock(ctx context.Context, arg database.GetBlockParams) (database.Block, error)
+	ListBlocks(ctx context.Context, blockerID uuid.UUID) ([]database.Block, error)
+	CreateMute(ctx context.Context, arg database.CreateMuteParams) (database.Mute, error)
+	DeleteMute(ctx context.Context, arg database.DeleteMuteParams) (int64, error)
+	ListMutes(ctx context.Context, muterID uuid.UUID) ([]database.Mute, error)
+	ListHiddenAuthors(ctx context.Context, arg database.ListHiddenAuthorsParams) ([]database.ListHiddenAuthorsRow, error)
+
+	// Notifications
+	CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.Notification, error)
+	GetUnreadNotificationGroup(ctx context.Context, arg database.GetUnreadNotificationGroupParams) (database.Notification, error)
+	AddNotificationActor(ctx context.Context, arg database.AddNotificationActorParams) (int64, error)
+	BumpNotification(ctx context.Context, arg database.BumpNotificationParams) (database.Notification, error)
+	ListNotifications(ctx context.Context, arg database.ListNotificationsParams) ([]database.Notification, error)
+	ListUnreadNotifications(ctx context.Context, arg database.ListUnreadNotificationsParams) ([]database.Notification, error)
+	CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error)
+	MarkNotificationRead(ctx context.Context, arg database.MarkNotificationReadParams) (int64, error)
+	MarkAllNotificationsRead(ctx context.Context, arg database.MarkAllNotificationsReadParams) (int64, error)
+
+	// Reports
+	CreateReport(ctx context.Context, arg database.CreateReportParams) (database.Report, error)
+	GetReport(ctx context.Context, id uuid.UUID) (database.Report, error)
+	ListReports(ctx context.Context, status string) ([]database.ListReportsRow, error)
+	ResolveReport(ctx context.Context, arg database.ResolveReportParams) (database.Report, error)
+	ResolveOpenReportsForChirp(ctx context.Context, arg database.ResolveOpenReportsForChirpParams) (int64, error)
+
+	// Moderation wordlist
+	CreateModerationWord(ctx context.Context, arg database.CreateModerationWordParams) (database.ModerationWord, error)
+	ListModerationWords(ctx context.Context) ([]database.ModerationWord, error)
+	UpdateModerationWord(ctx context.Context, arg database.UpdateModerationWordParams) (database.ModerationWord, error)
+	DeleteModerationWord(ctx context.Context, id uuid.UUID) (int64, error)
+
+	// Outbox
+	InsertOutboxEvent(ctx context.Context, arg database.InsertOutboxEventParams) error
+
+	// InTx runs fn against a Store whose writes commit together when fn
+	// returns nil and are discarded otherwise. Calling InTx on the Store
+	// passed to fn runs in the same transaction.
+	InTx(ctx context.Context, fn func(Store) error) error
+}
@@ -0,0 +1,288 @@
+// Package stream fans chirp events out to live clients, such as the
+// Server-Sent Events and WebSocket endpoints, and keeps a short history so clients that
+// reconnect can resume where they left off.
+package stream
+
+import (
+	"chirpy/internal/logger"
+	"chirpy/internal/metrics"
+	"chirpy/internal/pubsub"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultReplaySize   = 1000
+	defaultClientBuffer = 64
+	defaultHeartbeat    = 15 * time.Second
+	// publishTimeout bounds handing an event to the PubSub.
+	publishTimeout = 5 * time.Second
+)
+
+var (
+	// ErrSlowConsumer ends a subscription whose buffer filled up. The
+	// client should reconnect and resume from the last event it saw.
+	ErrSlowConsumer = errors.New("stream: client too slow")
+	// ErrClosed ends every subscription when the hub shuts down.
+	ErrClosed = errors.New("stream: hub closed")
+)
+
+// Event is one published change. IDs increase by one per event within
+// the hub's epoch, a random tag each hub picks when created, so an ID
+// from another instance or from before a restart is told apart rather
+// than misread.
+type Event struct {
+	ID       uint64
+	Epoch    string
+	Type     string
+	AuthorID uuid.UUID
+	// Recipient is set on events meant for one user only, such as
+	// notifications; uuid.Nil means the event is public.
+	Recipient uuid.UUID
+	Data      json.RawMessage
+}
+
+// StreamID is the ID clients see and resume from: "<epoch>-<id>".
+func (e Event) StreamID() string {
+	return e.Epoch + "-" + strconv.FormatUint(e.ID, 10)
+}
+
+// Hub delivers published events to subscribers without ever blocking the
+// publisher: each subscriber has a bounded buffer and is dropped with
+// ErrSlowConsumer when it overflows.
+type Hub struct {
+	// ReplaySize is how many recent events are kept for resuming.
+	ReplaySize int
+	// ClientBuffer is how many events may queue for one subscriber.
+	ClientBuffer int
+	// Heartbeat is how often idle connections are sent a keepalive: an SSE
+	// comment or a WebSocket ping.
+	Heartbeat time.Duration
+
+	// bus, when attached, carries events to the hubs of other instances.
+	bus pubsub.PubSub
+
+	mu     sync.Mutex
+	epoch  string
+	lastID uint64
+	replay []Event // oldest first
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewHub returns a Hub with the default sizes.
+func NewHub() *Hub {
+	id := uuid.New()
+	return &Hub{
+		epoch:        hex.EncodeToString(id[:6]),
+		ReplaySize:   defaultReplaySize,
+		ClientBuffer: defaultClientBuffer,
+		Heartbeat:    defaultHeartbeat,
+		subs:         make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscription receives the events accepted by its filter until Done is
+// closed.
+type Subscription struct {
+	events chan Event
+	done   chan struct{}
+	filter func(Event) bool
+	err    error
+}
+
+// Events delivers matching events in publish order.
+func (s *Subscription) Events() <-chan Event { return s.events }
+
+// Done is closed when the hub drops the subscription; Err says why.
+func (s *Subscription) Done() <-chan struct{} { return s.done }
+
+// Err is ErrSlowConsumer or ErrClosed once Done is closed, nil before.
+func (s *Subscription) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Publish records an event and hands it to every matching subscriber,
+// on every instance once the hub is attached to a shared PubSub. data is
+// encoded as JSON. A nil Hub discards events, so callers need not check
+// whether streaming is enabled.
+func (h *Hub) Publish(eventType string, authorID uuid.UUID, data any) {
+	h.publish(eventType, authorID, uuid.Nil, data)
+}
+
+// PublishTo is Publish for an event only recipient may see. authorID is
+// the user whose action caused it.
+func (h *Hub) PublishTo(recipient uuid.UUID, eventType string, authorID uuid.UUID, data any) {
+	h.publish(eventType, authorID, recipient, data)
+}
+
+func (h *Hub) publish(eventType string, authorID, recipient uuid.UUID, data any) {
+	if h == nil {
+		return
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		logger.Logger.Errorw("Failed to encode stream event", "event_type", eventType, "error", err)
+		return
+	}
+	if h.bus == nil {
+		h.deliver(eventType, authorID, recipient, raw)
+		return
+	}
+
+	// The bus delivers to this hub too, through the Attach subscription.
+	msg, err := json.Marshal(busEvent{Type: eventType, AuthorID: authorID, Recipient: recipient, Data: raw})
+	if err != nil {
+		logger.Logger.Errorw("Failed to encode stream event", "event_type", eventType, "error", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	if err := h.bus.Publish(ctx, pubsub.TopicStream, msg); err != nil {
+		logger.Logger.Warnw("Failed to share stream event with other instances", "event_type", eventType, "error", err)
+	}
+}
+
+// busEvent is an Event on its way between instances. IDs are assigned by
+// each receiving hub, in its own epoch.
+type busEvent struct {
+	Type      string          `json:"type"`
+	AuthorID  uuid.UUID       `json:"author_id"`
+	Recipient uuid.UUID       `json:"recipient,omitempty"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Attach routes the hub's events through ps, which must deliver
+// publishes to local subscribers as well, so that every hub attached to
+// the same shared PubSub sees every event. Call it before the hub is
+// used.
+func (h *Hub) Attach(ps pubsub.PubSub) error {
+	_, err := ps.Subscribe(pubsub.TopicStream, func(payload []byte) {
+		var evt busEvent
+		if err := json.Unmarshal(payload, &evt); err != nil {
+			logger.Logger.Warnw("Discarding malformed stream event", "error", err)
+			return
+		}
+		h.deliver(evt.Type, evt.AuthorID, evt.Recipient, evt.Data)
+	})
+	if err != nil {
+		return err
+	}
+	h.bus = ps
+	return nil
+}
+
+// deliver assigns the next ID to an event and fans it out.
+func (h *Hub) deliver(eventType string, authorID, recipient uuid.UUID, raw json.RawMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.lastID++
+	evt := Event{ID: h.lastID, Epoch: h.epoch, Type: eventType, AuthorID: authorID, Recipient: recipient, Data: raw}
+
+	h.replay = append(h.replay, evt)
+	if over := len(h.replay) - h.ReplaySize; over > 0 {
+		h.replay = h.replay[over:]
+	}
+
+	for sub := range h.subs {
+		if sub.filter != nil && !sub.filter(evt) {
+			continue
+		}
+		select {
+		case sub.events <- evt:
+		default:
+			h.drop(sub, ErrSlowConsumer)
+			metrics.StreamDroppedTotal.Inc()
+		}
+	}
+}
+
+// Subscribe registers a subscriber for events accepted by filter (nil
+// accepts all). Given the StreamID of the last event a client saw, it
+// also returns the buffered events after it, and complete reports whether
+// they cover everything the client missed; when false the client has to
+// reload its state. An ID from another epoch, or one that does not parse,
+// is never complete. Both happen under one lock, so no event is lost or
+// repeated between the replay and the live feed.
+func (h *Hub) Subscribe(lastEventID string, filter func(Event) bool) (sub *Subscription, replay []Event, complete bool) {
+	sub = &Subscription{
+		events: make(chan Event, h.ClientBuffer),
+		done:   make(chan struct{}),
+		filter: filter,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		sub.err = ErrClosed
+		close(sub.done)
+		return sub, nil, false
+	}
+	h.subs[sub] = struct{}{}
+	metrics.StreamClients.Inc()
+
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+	epoch, seq, _ := strings.Cut(lastEventID, "-")
+	lastID, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil || epoch != h.epoch {
+		return sub, nil, false
+	}
+	complete = lastID <= h.lastID
+	if len(h.replay) > 0 && lastID+1 < h.replay[0].ID {
+		complete = false
+	}
+	for _, evt := range h.replay {
+		if evt.ID > lastID && (filter == nil || filter(evt)) {
+			replay = append(replay, evt)
+		}
+	}
+	return sub, replay, complete
+}
+
+// Unsubscribe removes sub. It is safe to call more than once and after
+// the hub dropped it.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; ok {
+		h.drop(sub, nil)
+	}
+}
+
+// Close ends every subscription with ErrClosed and ignores later
+// publishes. Long-lived stream responses return promptly, so register it
+// with http.Server.RegisterOnShutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subs {
+		h.drop(sub, ErrClosed)
+	}
+}
+
+// drop must be called with h.mu held.
+func (h *Hub) drop(sub *Subscription, err error) {
+	delete(h.subs, sub)
+	sub.err = err
+	close(sub.done)
+	metrics.StreamClients.Dec()
+}
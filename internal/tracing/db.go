@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapDBTX returns a database.DBTX that records a span for every query,
+// tagged with the database behind driver ("postgres" or "sqlite"). Use
+// it for both the pool and transactions:
+//
+//	database.New(tracing.WrapDBTX(db, driver))
+//	database.New(tracing.WrapDBTX(tx, driver))
+//
+// Query spans last until their rows are closed when db was opened with
+// OpenDB, and end once the query returns otherwise.
+func WrapDBTX(db database.DBTX, driver string) database.DBTX {
+	return &tracedDBTX{db: db, system: dbSystem(driver)}
+}
+
+type tracedDBTX struct {
+	db     database.DBTX
+	system string
+}
+
+// dbSystem maps a driver name to its OpenTelemetry db.system value.
+func dbSystem(driver string) string {
+	if driver == "postgres" {
+		return "postgresql"
+	}
+	return driver
+}
+
+func (t *tracedDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := t.startQuerySpan(ctx, query)
+	defer span.End()
+
+	res, err := t.db.ExecContext(ctx, query, args...)
+	recordError(span, err)
+	return res, err
+}
+
+func (t *tracedDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := t.startQuerySpan(ctx, query)
+	defer span.End()
+
+	stmt, err := t.db.PrepareContext(ctx, query)
+	recordError(span, err)
+	return stmt, err
+}
+
+func (t *tracedDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := t.startQuerySpan(ctx, query)
+	ctx, pending := withPendingSpan(ctx, span)
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	recordError(span, err)
+	if !pending.taken {
+		span.End()
+	}
+	return rows, err
+}
+
+func (t *tracedDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := t.startQuerySpan(ctx, query)
+	ctx, pending := withPendingSpan(ctx, span)
+
+	row := t.db.QueryRowContext(ctx, query, args...)
+	recordError(span, row.Err())
+	if !pending.taken {
+		span.End()
+	}
+	return row
+}
+
+func (t *tracedDBTX) startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := queryName(query)
+	return Tracer().Start(ctx, "db."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", t.system),
+			attribute.String("db.operation.name", name),
+		),
+	)
+}
+
+// queryName extracts the sqlc query name from the "-- name: X :kind"
+// header that sqlc keeps at the top of every generated statement.
+func queryName(query string) string {
+	const prefix = "-- name: "
+	if !strings.HasPrefix(query, prefix) {
+		return "query"
+	}
+	fields := strings.Fields(query[len(prefix):])
+	if len(fields) == 0 {
+		return "query"
+	}
+	return fields[0]
+}
+
+func recordError(span trace.Span, err error) {
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -5,8 +5,14 @@ import (
 	"net/http"
 )
 
+// RespondWithError writes {"error": msg}, plus the request ID when the
+// RequestID middleware has set one on the response.
 func RespondWithError(w http.ResponseWriter, code int, msg string) {
-	RespondWithJSON(w, code, map[string]string{"error": msg})
+	body := map[string]string{"error": msg}
+	if id := w.Header().Get("X-Request-ID"); id != "" {
+		body["request_id"] = id
+	}
+	RespondWithJSON(w, code, body)
 }
 
 func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
@@ -0,0 +1,330 @@
+package test
+
+import (
+	"bytes"
+	"chirpy/internal/api"
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	"chirpy/internal/health"
+	"chirpy/internal/models"
+	"chirpy/internal/server"
+	"chirpy/internal/store"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	e2eJWTSecret = "e2e-jwt-secret"
+	e2ePolkaKey  = "e2e-polka-key"
+)
+
+// e2eClient talks to a full Chirpy server over real HTTP.
+type e2eClient struct {
+	t   *testing.T
+	srv *httptest.Server
+	cfg *api.Config
+}
+
+func newE2E(t *testing.T, st store.Store, platform string) *e2eClient {
+	t.Helper()
+	cfg := &api.Config{
+		DB:              st,
+		Health:          health.NewChecker(),
+		Platform:        platform,
+		JWTSecret:       e2eJWTSecret,
+		PolkaKey:        e2ePolkaKey,
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: 24 * time.Hour,
+		ChirpMaxLength:  140,
+		QueryTimeout:    5 * time.Second,
+	}
+	srv := httptest.NewServer(server.New(cfg))
+	t.Cleanup(srv.Close)
+	return &e2eClient{t: t, srv: srv, cfg: cfg}
+}
+
+// do sends body (a string is sent verbatim, anything else as JSON) with
+// an Authorization header when auth is set, and returns status and body.
+func (c *e2eClient) do(method, path, authz string, body any) (int, []byte) {
+	c.t.Helper()
+	var r io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		r = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		require.NoError(c.t, err)
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.srv.URL+path, r)
+	require.NoError(c.t, err)
+	if authz != "" {
+		req.Header.Set("Authorization", authz)
+	}
+	resp, err := c.srv.Client().Do(req)
+	require.NoError(c.t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(c.t, err)
+	return resp.StatusCode, data
+}
+
+func (c *e2eClient) decode(data []byte, v any) {
+	c.t.Helper()
+	require.NoError(c.t, json.Unmarshal(data, v), string(data))
+}
+
+func (c *e2eClient) signup(email, password string) models.CreateUserResponse {
+	c.t.Helper()
+	status, body := c.do("POST", "/api/users", "", models.CreateUserRequest{Email: email, Password: password})
+	require.Equal(c.t, http.StatusCreated, status, string(body))
+	var u models.CreateUserResponse
+	c.decode(body, &u)
+	return u
+}
+
+func (c *e2eClient) login(email, password string) models.LoginResponse {
+	c.t.Helper()
+	status, body := c.do("POST", "/api/login", "", models.LoginRequest{Email: email, Password: password})
+	require.Equal(c.t, http.StatusOK, status, string(body))
+	var l models.LoginResponse
+	c.decode(body, &l)
+	return l
+}
+
+// staff signs up a user, grants role directly in the store, as the
+// admin CLI would, and logs in.
+func (c *e2eClient) staff(email, role string) models.LoginResponse {
+	c.t.Helper()
+	u := c.signup(email, "pw")
+	_, err := c.cfg.DB.UpdateUserRole(context.Background(), database.UpdateUserRoleParams{
+		ID: u.ID, Role: role, UpdatedAt: time.Now().UTC(),
+	})
+	require.NoError(c.t, err)
+	return c.login(email, "pw")
+}
+
+func (c *e2eClient) chirp(token, text string) models.ChirpResponse {
+	c.t.Helper()
+	status, body := c.do("POST", "/api/chirps", bearer(token), models.ChirpRequest{Body: text})
+	require.Equal(c.t, http.StatusCreated, status, string(body))
+	var ch models.ChirpResponse
+	c.decode(body, &ch)
+	return ch
+}
+
+func bearer(token string) string { return "Bearer " + token }
+
+func TestE2E_HappyPath(t *testing.T) {
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			c := newE2E(t, st, "dev")
+
+			// Signup → login
+			user := c.signup("walt@example.com", "hunter2")
+			assert.False(t, user.IsChirpyRed)
+			login := c.login("walt@example.com", "hunter2")
+			assert.Equal(t, user.ID, login.ID)
+			assert.NotEmpty(t, login.Token)
+			assert.NotEmpty(t, login.RefreshToken)
+
+			// Chirp → read back
+			ch := c.chirp(login.Token, "I am the one who knocks, kerfuffle")
+			assert.Equal(t, "I am the one who knocks, ****", ch.Body)
+			assert.Equal(t, user.ID, ch.UserID)
+			c.chirp(login.Token, "Say my name")
+
+			status, body := c.do("GET", "/api/chirps?sort=desc", "", nil)
+			require.Equal(t, http.StatusOK, status)
+			var chirps []models.ChirpResponse
+			c.decode(body, &chirps)
+			require.Len(t, chirps, 2)
+
+			status, body = c.do("GET", "/api/chirps/"+ch.ID.String(), "", nil)
+			require.Equal(t, http.StatusOK, status)
+			var got models.ChirpResponse
+			c.decode(body, &got)
+			assert.Equal(t, ch, got)
+
+			// Refresh → revoke
+			status, body = c.do("POST", "/api/refresh", bearer(login.RefreshToken), nil)
+			require.Equal(t, http.StatusOK, status, string(body))
+			var refreshed struct {
+				Token string `json:"token"`
+			}
+			c.decode(body, &refreshed)
+			status, _ = c.do("PUT", "/api/users", bearer(refreshed.Token),
+				models.UpdateUserRequest{Email: "heisenberg@example.com", Password: "bluesky"})
+			assert.Equal(t, http.StatusOK, status, "refreshed access token works")
+
+			status, _ = c.do("POST", "/api/revoke", bearer(login.RefreshToken), nil)
+			assert.Equal(t, http.StatusNoContent, status)
+			status, _ = c.do("POST", "/api/refresh", bearer(login.RefreshToken), nil)
+			assert.Equal(t, http.StatusUnauthorized, status)
+
+			// Webhook → Chirpy Red
+			status, body = c.do("POST", "/api/polka/webhooks", "ApiKey "+e2ePolkaKey,
+				`{"event":"user.upgraded","data":{"user_id":"`+user.ID.String()+`"}}`)
+			require.Equal(t, http.StatusNoContent, status, string(body))
+			relogin := c.login("heisenberg@example.com", "bluesky")
+			assert.True(t, relogin.IsChirpyRed)
+
+			// Delete → gone
+			status, _ = c.do("DELETE", "/api/chirps/"+ch.ID.String(), bearer(relogin.Token), nil)
+			assert.Equal(t, http.StatusNoContent, status)
+			status, _ = c.do("GET", "/api/chirps/"+ch.ID.String(), "", nil)
+			assert.Equal(t, http.StatusNotFound, status)
+
+			// Reset (dev only, admins only)
+			status, _ = c.do("POST", "/admin/reset", bearer(relogin.Token), nil)
+			assert.Equal(t, http.StatusForbidden, status)
+			admin := c.staff("admin@example.com", "admin")
+			status, _ = c.do("POST", "/admin/reset", bearer(admin.Token), nil)
+			assert.Equal(t, http.StatusOK, status)
+			status, body = c.do("GET", "/api/chirps", "", nil)
+			require.Equal(t, http.StatusOK, status)
+			assert.JSONEq(t, "[]", string(body))
+		})
+	}
+}
+
+func TestE2E_ErrorBranches(t *testing.T) {
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			c := newE2E(t, st, "prod")
+			ctx := context.Background()
+
+			author := c.signup("author@example.com", "pw-author")
+			authorLogin := c.login("author@example.com", "pw-author")
+			c.signup("other@example.com", "pw-other")
+			otherLogin := c.login("other@example.com", "pw-other")
+			ch := c.chirp(authorLogin.Token, "mine")
+			admin := c.staff("admin@example.com", "admin")
+
+			expiredJWT, err := auth.MakeJWT(author.ID, e2eJWTSecret, -time.Minute)
+			require.NoError(t, err)
+			foreignJWT, err := auth.MakeJWT(author.ID, "some-other-secret", time.Hour)
+			require.NoError(t, err)
+			ghostJWT, err := auth.MakeJWT(uuid.New(), e2eJWTSecret, time.Hour)
+			require.NoError(t, err)
+
+			// Refresh tokens in states the API cannot produce directly.
+			now := time.Now().UTC()
+			for token, expires := range map[string]time.Time{
+				"expired-refresh": now.Add(-time.Hour),
+				"revoked-refresh": now.Add(time.Hour),
+			} {
+				require.NoError(t, st.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+					Token: token, UserID: author.ID, ExpiresAt: expires, CreatedAt: now, UpdatedAt: now,
+				}))
+			}
+			require.NoError(t, st.RevokeRefreshToken(ctx, database.RevokeRefreshTokenParams{
+				RevokedAt: sql.NullTime{Time: now, Valid: true}, UpdatedAt: now, Token: "revoked-refresh",
+			}))
+
+			chirpPath := "/api/chirps/" + ch.ID.String()
+			missingChirp := "/api/chirps/" + uuid.New().String()
+			webhook := func(event, userID string) string {
+				return `{"event":"` + event + `","data":{"user_id":"` + userID + `"}}`
+			}
+
+			tests := []struct {
+				name   string
+				method string
+				path   string
+				authz  string
+				body   any
+				want   int
+			}{
+				// Signup
+				{"signup invalid JSON", "POST", "/api/users", "", "{", http.StatusBadRequest},
+				{"signup missing email", "POST", "/api/users", "", models.CreateUserRequest{Password: "x"}, http.StatusBadRequest},
+				{"signup missing password", "POST", "/api/users", "", models.CreateUserRequest{Email: "new@example.com"}, http.StatusBadRequest},
+				{"signup duplicate email", "POST", "/api/users", "", models.CreateUserRequest{Email: "author@example.com", Password: "x"}, http.StatusConflict},
+				{"users wrong method", "PATCH", "/api/users", "", nil, http.StatusMethodNotAllowed},
+
+				// Login
+				{"login invalid JSON", "POST", "/api/login", "", "{", http.StatusBadRequest},
+				{"login missing password", "POST", "/api/login", "", models.LoginRequest{Email: "author@example.com"}, http.StatusBadRequest},
+				{"login unknown email", "POST", "/api/login", "", models.LoginRequest{Email: "nobody@example.com", Password: "x"}, http.StatusUnauthorized},
+				{"login wrong password", "POST", "/api/login", "", models.LoginRequest{Email: "author@example.com", Password: "wrong"}, http.StatusUnauthorized},
+
+				// Update user
+				{"update no token", "PUT", "/api/users", "", models.UpdateUserRequest{Email: "a@b.c", Password: "x"}, http.StatusUnauthorized},
+				{"update expired token", "PUT", "/api/users", bearer(expiredJWT), models.UpdateUserRequest{Email: "a@b.c", Password: "x"}, http.StatusUnauthorized},
+				{"update foreign token", "PUT", "/api/users", bearer(foreignJWT), models.UpdateUserRequest{Email: "a@b.c", Password: "x"}, http.StatusUnauthorized},
+				{"update invalid JSON", "PUT", "/api/users", bearer(authorLogin.Token), "{", http.StatusBadRequest},
+				{"update missing email", "PUT", "/api/users", bearer(authorLogin.Token), models.UpdateUserRequest{Password: "x"}, http.StatusBadRequest},
+				{"update missing password", "PUT", "/api/users", bearer(authorLogin.Token), models.UpdateUserRequest{Email: "a@b.c"}, http.StatusBadRequest},
+
+				// Create chirp
+				{"chirp no token", "POST", "/api/chirps", "", models.ChirpRequest{Body: "x"}, http.StatusUnauthorized},
+				{"chirp malformed header", "POST", "/api/chirps", "Token abc", models.ChirpRequest{Body: "x"}, http.StatusUnauthorized},
+				{"chirp expired token", "POST", "/api/chirps", bearer(expiredJWT), models.ChirpRequest{Body: "x"}, http.StatusUnauthorized},
+				{"chirp invalid JSON", "POST", "/api/chirps", bearer(authorLogin.Token), "{", http.StatusBadRequest},
+				{"chirp too long", "POST", "/api/chirps", bearer(authorLogin.Token), models.ChirpRequest{Body: strings.Repeat("x", 141)}, http.StatusBadRequest},
+				{"chirp by unknown user", "POST", "/api/chirps", bearer(ghostJWT), models.ChirpRequest{Body: "x"}, http.StatusBadRequest},
+
+				// Read chirps
+				{"list invalid author_id", "GET", "/api/chirps?author_id=nope", "", nil, http.StatusBadRequest},
+				{"get invalid ID", "GET", "/api/chirps/nope", "", nil, http.StatusBadRequest},
+				{"get unknown ID", "GET", missingChirp, "", nil, http.StatusNotFound},
+
+				// Delete chirp
+				{"delete invalid ID", "DELETE", "/api/chirps/nope", bearer(authorLogin.Token), nil, http.StatusBadRequest},
+				{"delete no token", "DELETE", chirpPath, "", nil, http.StatusUnauthorized},
+				{"delete foreign token", "DELETE", chirpPath, bearer(foreignJWT), nil, http.StatusUnauthorized},
+				{"delete unknown ID", "DELETE", missingChirp, bearer(authorLogin.Token), nil, http.StatusNotFound},
+				{"delete someone else's chirp", "DELETE", chirpPath, bearer(otherLogin.Token), nil, http.StatusForbidden},
+
+				// Refresh
+				{"refresh no token", "POST", "/api/refresh", "", nil, http.StatusUnauthorized},
+				{"refresh unknown token", "POST", "/api/refresh", bearer("not-a-token"), nil, http.StatusUnauthorized},
+				{"refresh expired token", "POST", "/api/refresh", bearer("expired-refresh"), nil, http.StatusUnauthorized},
+				{"refresh revoked token", "POST", "/api/refresh", bearer("revoked-refresh"), nil, http.StatusUnauthorized},
+
+				// Revoke
+				{"revoke no token", "POST", "/api/revoke", "", nil, http.StatusUnauthorized},
+				{"revoke unknown token is idempotent", "POST", "/api/revoke", bearer("not-a-token"), nil, http.StatusNoContent},
+
+				// Webhook
+				{"webhook no key", "POST", "/api/polka/webhooks", "", webhook("user.upgraded", author.ID.String()), http.StatusUnauthorized},
+				{"webhook bearer instead of key", "POST", "/api/polka/webhooks", bearer(e2ePolkaKey), webhook("user.upgraded", author.ID.String()), http.StatusUnauthorized},
+				{"webhook wrong key", "POST", "/api/polka/webhooks", "ApiKey wrong", webhook("user.upgraded", author.ID.String()), http.StatusUnauthorized},
+				{"webhook invalid JSON", "POST", "/api/polka/webhooks", "ApiKey " + e2ePolkaKey, "{", http.StatusBadRequest},
+				{"webhook other event ignored", "POST", "/api/polka/webhooks", "ApiKey " + e2ePolkaKey, webhook("user.downgraded", author.ID.String()), http.StatusNoContent},
+				{"webhook invalid user_id", "POST", "/api/polka/webhooks", "ApiKey " + e2ePolkaKey, webhook("user.upgraded", "nope"), http.StatusBadRequest},
+				{"webhook unknown user", "POST", "/api/polka/webhooks", "ApiKey " + e2ePolkaKey, webhook("user.upgraded", uuid.New().String()), http.StatusNotFound},
+
+				// Admin
+				{"reset no token", "POST", "/admin/reset", "", nil, http.StatusUnauthorized},
+				{"reset unknown user", "POST", "/admin/reset", bearer(ghostJWT), nil, http.StatusUnauthorized},
+				{"reset as user", "POST", "/admin/reset", bearer(otherLogin.Token), nil, http.StatusForbidden},
+				{"reset outside dev", "POST", "/admin/reset", bearer(admin.Token), nil, http.StatusForbidden},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					status, body := c.do(tt.method, tt.path, tt.authz, tt.body)
+					assert.Equal(t, tt.want, status, string(body))
+				})
+			}
+
+			// Nothing above may have changed the author's chirp.
+			status, _ := c.do("GET", chirpPath, "", nil)
+			assert.Equal(t, http.StatusOK, status)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package test
+
+import (
+	"bytes"
+	"chirpy/internal/api"
+	"chirpy/internal/config"
+	"chirpy/internal/handlers"
+	"chirpy/internal/migrate"
+	"chirpy/internal/models"
+	"chirpy/internal/store"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStores returns a fresh store per backend. Postgres is included
+// when TEST_DB_URL points at a database the tests may wipe.
+func testStores(t *testing.T) map[string]store.Store {
+	t.Helper()
+	stores := map[string]store.Store{
+		config.DriverMemory: store.NewMemory(),
+		config.DriverSQLite: openTestDB(t, config.DriverSQLite, "sqlite://"+filepath.Join(t.TempDir(), "chirpy.db")),
+	}
+	if url := os.Getenv("TEST_DB_URL"); url != "" {
+		stores[config.DriverPostgres] = openTestDB(t, config.DriverPostgres, url)
+	}
+	return stores
+}
+
+func openTestDB(t *testing.T, driver, url string) store.Store {
+	t.Helper()
+	ctx := context.Background()
+	db, err := store.Open(driver, url)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = migrate.Up(ctx, db, driver)
+	require.NoError(t, err)
+	s := store.NewSQL(db, driver)
+	require.NoError(t, s.DeleteAllUsers(ctx))
+	_, err = db.ExecContext(ctx, "DELETE FROM moderation_words")
+	require.NoError(t, err)
+	return s
+}
+
+// TestHandlers drives the chirp lifecycle through the real handlers on
+// every backend.
+func TestHandlers(t *testing.T) {
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			testChirpLifecycle(t, st)
+		})
+	}
+}
+
+func testChirpLifecycle(t *testing.T, st store.Store) {
+	cfg := &api.Config{
+		DB:              st,
+		JWTSecret:       "test-secret",
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: time.Hour,
+		ChirpMaxLength:  140,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/users", handlers.HandleCreateUser(cfg))
+	mux.HandleFunc("PUT /api/users", handlers.HandleUpdateUser(cfg))
+	mux.HandleFunc("POST /api/login", handlers.HandleLogin(cfg))
+	mux.HandleFunc("POST /api/refresh", handlers.HandleTokenRefresh(cfg))
+	mux.HandleFunc("POST /api/revoke", handlers.HandleTokenRevoke(cfg))
+	mux.HandleFunc("POST /api/chirps", handlers.HandleCreateChirp(cfg))
+	mux.HandleFunc("GET /api/chirps", handlers.HandleGetAllChirps(cfg))
+	mux.HandleFunc("GET /api/chirps/{chirpID}", handlers.HandleGetChirpByID(cfg))
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}", handlers.HandleDeleteChirp(cfg))
+
+	do := func(method, path, token string, body any) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req := httptest.NewRequest(method, path, &buf)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	creds := models.CreateUserRequest{Email: "walt@example.com", Password: "hunter2"}
+	rec := do("POST", "/api/users", "", creds)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = do("POST", "/api/users", "", creds)
+	assert.Equal(t, http.StatusConflict, rec.Code, "duplicate email")
+
+	rec = do("POST", "/api/login", "", models.LoginRequest{Email: creds.Email, Password: creds.Password})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var login models.LoginResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &login))
+
+	rec = do("POST", "/api/refresh", login.RefreshToken, nil)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = do("PUT", "/api/users", login.Token, models.UpdateUserRequest{Email: "heisenberg@example.com", Password: "hunter3"})
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = do("POST", "/api/chirps", login.Token, models.ChirpRequest{Body: "hello"})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var chirp models.ChirpResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &chirp))
+
+	rec = do("GET", "/api/chirps?author_id="+login.ID.String(), "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var chirps []models.ChirpResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &chirps))
+	require.Len(t, chirps, 1)
+	assert.Equal(t, "hello", chirps[0].Body)
+
+	rec = do("DELETE", "/api/chirps/"+chirp.ID.String(), login.Token, nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec = do("GET", "/api/chirps/"+chirp.ID.String(), "", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = do("POST", "/api/revoke", login.RefreshToken, nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec = do("POST", "/api/refresh", login.RefreshToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "revoked refresh token")
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"chirpy/internal/api"
+	"chirpy/internal/handlers"
+	"chirpy/internal/health"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_Run(t *testing.T) {
+	checker := health.NewChecker()
+	checker.Timeout = 50 * time.Millisecond
+	checker.Register("ok", func(ctx context.Context) error { return nil })
+	checker.Register("broken", func(ctx context.Context) error { return errors.New("boom") })
+	checker.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := checker.Run(context.Background())
+
+	assert.Equal(t, health.StatusFail, report.Status)
+	assert.Equal(t, health.StatusOK, report.Components["ok"].Status)
+	assert.Equal(t, "boom", report.Components["broken"].Error)
+	assert.Equal(t, health.StatusFail, report.Components["slow"].Status)
+	assert.Contains(t, report.Components["slow"].Error, "deadline exceeded")
+
+	brief := report.Brief()
+	assert.Empty(t, brief.Components["broken"].Error)
+	assert.Empty(t, brief.Components["broken"].Duration)
+}
+
+func TestHandleReadyz(t *testing.T) {
+	tests := []struct {
+		name         string
+		shuttingDown bool
+		query        string
+		healthToken  string
+		authz        string
+		wantStatus   int
+		wantError    bool
+	}{
+		{name: "ready", wantStatus: http.StatusOK},
+		{name: "shutting down", shuttingDown: true, wantStatus: http.StatusServiceUnavailable},
+		{name: "shutting down verbose", shuttingDown: true, query: "?verbose=1", healthToken: "ops", authz: "Bearer ops", wantStatus: http.StatusServiceUnavailable, wantError: true},
+		{name: "verbose without token", query: "?verbose=1", healthToken: "ops", wantStatus: http.StatusForbidden},
+		{name: "verbose with wrong token", query: "?verbose=1", healthToken: "ops", authz: "Bearer nope", wantStatus: http.StatusForbidden},
+		{name: "verbose disabled", query: "?verbose=1", authz: "Bearer ", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &api.Config{Health: health.NewChecker(), HealthToken: tt.healthToken}
+			cfg.Health.Register("server", health.NotShuttingDown(cfg.ShuttingDown.Load))
+			cfg.ShuttingDown.Store(tt.shuttingDown)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/readyz"+tt.query, nil)
+			if tt.authz != "" {
+				req.Header.Set("Authorization", tt.authz)
+			}
+			handlers.HandleReadyz(cfg)(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusForbidden {
+				assert.NotContains(t, rec.Body.String(), "components")
+				return
+			}
+			var report health.Report
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+			assert.Equal(t, tt.wantError, report.Components["server"].Error != "")
+		})
+	}
+}
+
+func TestHandleLivez(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handlers.HandleLivez()(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
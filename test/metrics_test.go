@@ -0,0 +1,107 @@
+package test
+
+import (
+	"chirpy/internal/api"
+	"chirpy/internal/config"
+	"chirpy/internal/health"
+	"chirpy/internal/metrics"
+	"chirpy/internal/middleware"
+	"chirpy/internal/server"
+	"chirpy/internal/store"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsMiddleware_LabelsByRoutePattern(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/chirps/{chirpID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := middleware.Metrics(mux)
+
+	before := testutil.ToFloat64(metrics.HTTPRequestsTotal.WithLabelValues("GET /api/chirps/{chirpID}", "GET", "404"))
+
+	for _, id := range []string{"a", "b", "c"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/chirps/"+id, nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	}
+
+	after := testutil.ToFloat64(metrics.HTTPRequestsTotal.WithLabelValues("GET /api/chirps/{chirpID}", "GET", "404"))
+	assert.Equal(t, 3.0, after-before)
+}
+
+func TestMetricsMiddleware_UnmatchedRoute(t *testing.T) {
+	handler := middleware.Metrics(http.NewServeMux())
+
+	before := testutil.ToFloat64(metrics.HTTPRequestsTotal.WithLabelValues("unmatched", "GET", "404"))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/nope", nil))
+
+	after := testutil.ToFloat64(metrics.HTTPRequestsTotal.WithLabelValues("unmatched", "GET", "404"))
+	assert.Equal(t, 1.0, after-before)
+}
+
+func TestMetricsHandler_ExposesTextFormat(t *testing.T) {
+	metrics.ChirpsCreatedTotal.Inc()
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "chirpy_chirps_created_total")
+}
+
+func TestMetricsRoute_RequiresToken(t *testing.T) {
+	tests := []struct {
+		name         string
+		metricsToken string
+		authz        string
+		want         int
+	}{
+		{name: "scrape token", metricsToken: "scrape", authz: "Bearer scrape", want: http.StatusOK},
+		{name: "no token", metricsToken: "scrape", want: http.StatusForbidden},
+		{name: "wrong token", metricsToken: "scrape", authz: "Bearer nope", want: http.StatusForbidden},
+		{name: "token unset", authz: "Bearer ", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &api.Config{DB: store.NewMemory(), Health: health.NewChecker(), MetricsToken: tt.metricsToken}
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.authz != "" {
+				req.Header.Set("Authorization", tt.authz)
+			}
+			rec := httptest.NewRecorder()
+			server.New(cfg).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+			if tt.want == http.StatusOK {
+				assert.Contains(t, rec.Body.String(), "chirpy_chirps_created_total")
+			} else {
+				assert.NotContains(t, rec.Body.String(), "chirpy_")
+			}
+		})
+	}
+}
+
+func TestRegisterDBStats_Twice(t *testing.T) {
+	for range 2 {
+		db, err := store.Open(config.DriverSQLite, "sqlite://"+filepath.Join(t.TempDir(), "chirpy.db"))
+		require.NoError(t, err)
+		defer db.Close()
+		assert.NotPanics(t, func() { metrics.RegisterDBStats(db) })
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, 1, strings.Count(rec.Body.String(), `go_sql_max_open_connections{db_name="chirpy"}`))
+}
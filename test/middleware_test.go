@@ -0,0 +1,209 @@
+package test
+
+import (
+	"bytes"
+	"chirpy/internal/middleware"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain_Order(t *testing.T) {
+	var order []string
+	mark := func(name string) middleware.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	h := middleware.Chain(mark("a"), mark("b"), mark("c"))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		order = append(order, "handler")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, []string{"a", "b", "c", "handler"}, order)
+}
+
+func TestRecover(t *testing.T) {
+	t.Run("panic before response", func(t *testing.T) {
+		h := middleware.RequestID(middleware.Recover(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic("boom")
+		})))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Contains(t, rec.Body.String(), rec.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("panic after response started", func(t *testing.T) {
+		h := middleware.Recover(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			panic("boom")
+		}))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Empty(t, rec.Body.String())
+	})
+
+	t.Run("abort handler is re-raised", func(t *testing.T) {
+		h := middleware.Recover(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		})
+	})
+}
+
+func TestCORS(t *testing.T) {
+	h := middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins: []string{"https://chirpy.app"},
+		MaxAge:         10 * time.Minute,
+	})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		origin      string
+		preflight   bool
+		wantStatus  int
+		wantOrigin  string
+		wantMethods bool
+	}{
+		{name: "allowed origin", method: "GET", origin: "https://chirpy.app", wantStatus: http.StatusTeapot, wantOrigin: "https://chirpy.app"},
+		{name: "other origin", method: "GET", origin: "https://evil.example", wantStatus: http.StatusTeapot},
+		{name: "no origin", method: "GET", wantStatus: http.StatusTeapot},
+		{name: "preflight", method: "OPTIONS", origin: "https://chirpy.app", preflight: true, wantStatus: http.StatusNoContent, wantOrigin: "https://chirpy.app", wantMethods: true},
+		{name: "preflight from other origin", method: "OPTIONS", origin: "https://evil.example", preflight: true, wantStatus: http.StatusTeapot},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/chirps", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", "POST")
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantOrigin, rec.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tt.wantMethods, rec.Header().Get("Access-Control-Allow-Methods") != "")
+			assert.Contains(t, rec.Header().Values("Vary"), "Origin")
+			if tt.wantMethods {
+				assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+			}
+		})
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	ok := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	middleware.SecurityHeaders(middleware.SecurityOptions{})(ok).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, middleware.DefaultContentSecurityPolicy, rec.Header().Get("Content-Security-Policy"))
+	assert.Empty(t, rec.Header().Get("Strict-Transport-Security"))
+
+	rec = httptest.NewRecorder()
+	middleware.SecurityHeaders(middleware.SecurityOptions{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		ContentSecurityPolicy: "default-src 'none'",
+	})(ok).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "max-age=31536000; includeSubDomains", rec.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, "default-src 'none'", rec.Header().Get("Content-Security-Policy"))
+}
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat(`{"body":"chirp"},`, 200)
+	respond := func(contentType, body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if contentType != "" {
+				w.Header().Set("Content-Type", contentType)
+			}
+			io.WriteString(w, body)
+		})
+	}
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		body           string
+		wantEncoding   string
+	}{
+		{"gzip", "gzip", "application/json", large, "gzip"},
+		{"brotli preferred", "gzip, deflate, br", "application/json", large, "br"},
+		{"q-values respected", "br;q=0.5, gzip", "application/json", large, "gzip"},
+		{"identity only", "identity", "application/json", large, ""},
+		{"refused brotli via q=0", "br;q=0", "application/json", large, ""},
+		{"wildcard", "*", "application/json", large, "br"},
+		{"small body", "gzip", "application/json", `{"ok":true}`, ""},
+		{"already compressed type", "gzip", "image/png", large, ""},
+		{"sniffed content type", "gzip", "", large, "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			middleware.Compress(middleware.CompressOptions{})(respond(tt.contentType, tt.body)).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantEncoding, rec.Header().Get("Content-Encoding"))
+			assert.Contains(t, rec.Header().Values("Vary"), "Accept-Encoding")
+			assert.NotEmpty(t, rec.Header().Get("Content-Type"))
+			assert.Equal(t, tt.body, decodeBody(t, tt.wantEncoding, rec.Body.Bytes()))
+		})
+	}
+}
+
+func TestCompress_Flush(t *testing.T) {
+	h := middleware.Compress(middleware.CompressOptions{})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: 1\n\n")
+		require.NoError(t, http.NewResponseController(w).Flush())
+		io.WriteString(w, "data: 2\n\n")
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "data: 1\n\ndata: 2\n\n", decodeBody(t, "gzip", rec.Body.Bytes()))
+}
+
+func decodeBody(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader = bytes.NewReader(body)
+	switch encoding {
+	case "gzip":
+		zr, err := gzip.NewReader(r)
+		require.NoError(t, err)
+		r = zr
+	case "br":
+		r = brotli.NewReader(r)
+	}
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
@@ -0,0 +1,92 @@
+package test
+
+import (
+	"chirpy/internal/config"
+	"chirpy/internal/migrate"
+	"chirpy/internal/store"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatest_MatchesNewestSchemaFile(t *testing.T) {
+	files, err := filepath.Glob("../database/schema/*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	var newest int64
+	for _, f := range files {
+		prefix, _, _ := strings.Cut(filepath.Base(f), "_")
+		v, err := strconv.ParseInt(prefix, 10, 64)
+		require.NoError(t, err, f)
+		newest = max(newest, v)
+	}
+
+	latest, err := migrate.Latest(config.DriverPostgres)
+	require.NoError(t, err)
+	assert.Equal(t, newest, latest)
+}
+
+func TestChecker_ReportsPendingMigrations(t *testing.T) {
+	ctx := context.Background()
+	db, err := store.Open(config.DriverSQLite, "sqlite://"+filepath.Join(t.TempDir(), "chirpy.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	checker, err := migrate.NewChecker(db, config.DriverSQLite)
+	require.NoError(t, err)
+	assert.ErrorIs(t, checker.Check(ctx), migrate.ErrSchemaBehind)
+
+	_, err = migrate.Up(ctx, db, config.DriverSQLite)
+	require.NoError(t, err)
+	assert.NoError(t, checker.Check(ctx), "the same checker sees the new version")
+
+	_, err = migrate.Down(ctx, db, config.DriverSQLite)
+	require.NoError(t, err)
+	assert.ErrorIs(t, checker.Check(ctx), migrate.ErrSchemaBehind)
+}
+
+// Every Postgres migration needs a SQLite counterpart with the same
+// version so both backends report the same schema version.
+func TestSQLiteSchema_MirrorsPostgres(t *testing.T) {
+	pg, err := filepath.Glob("../database/schema/*.sql")
+	require.NoError(t, err)
+	lite, err := filepath.Glob("../database/schema/sqlite/*.sql")
+	require.NoError(t, err)
+
+	base := func(files []string) []string {
+		out := make([]string, len(files))
+		for i, f := range files {
+			out[i] = filepath.Base(f)
+		}
+		return out
+	}
+	assert.Equal(t, base(pg), base(lite))
+
+	pgLatest, err := migrate.Latest(config.DriverPostgres)
+	require.NoError(t, err)
+	liteLatest, err := migrate.Latest(config.DriverSQLite)
+	require.NoError(t, err)
+	assert.Equal(t, pgLatest, liteLatest)
+}
+
+func TestSchemaFiles_HaveUpAndDown(t *testing.T) {
+	files, err := filepath.Glob("../database/schema/*.sql")
+	require.NoError(t, err)
+	lite, err := filepath.Glob("../database/schema/sqlite/*.sql")
+	require.NoError(t, err)
+	files = append(files, lite...)
+
+	for _, f := range files {
+		body, err := os.ReadFile(f)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "-- +goose Up", f)
+		assert.Contains(t, string(body), "-- +goose Down", f)
+	}
+}
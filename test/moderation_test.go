@@ -0,0 +1,138 @@
+package test
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/models"
+	"chirpy/internal/moderation"
+	"chirpy/internal/profanity"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordlist_Reload(t *testing.T) {
+	opts := profanity.Options{Leetspeak: true, FoldRepeats: true}
+
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			// Two instances sharing one database.
+			a, err := moderation.NewWordlist(st, profanity.DefaultWords, opts)
+			require.NoError(t, err)
+			b, err := moderation.NewWordlist(st, profanity.DefaultWords, opts)
+			require.NoError(t, err)
+
+			changed, err := b.Reload(ctx)
+			require.NoError(t, err)
+			assert.True(t, changed, "first load compiles")
+			changed, err = b.Reload(ctx)
+			require.NoError(t, err)
+			assert.False(t, changed, "nothing stored changed")
+
+			now := time.Now().UTC()
+			word, err := st.CreateModerationWord(ctx, database.CreateModerationWordParams{
+				ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Word: "zorblax", Severity: "mask",
+			})
+			require.NoError(t, err)
+			_, err = a.Reload(ctx)
+			require.NoError(t, err)
+
+			changed, err = b.Reload(ctx)
+			require.NoError(t, err)
+			assert.True(t, changed)
+			got, err := b.Filter().Clean("what a z0rblax kerfuffle")
+			require.NoError(t, err)
+			assert.Equal(t, "what a **** ****", got)
+
+			// Escalating a built-in word blocks it.
+			_, err = st.UpdateModerationWord(ctx, database.UpdateModerationWordParams{
+				ID: word.ID, Word: "kerfuffle", Severity: "block", UpdatedAt: now.Add(time.Second),
+			})
+			require.NoError(t, err)
+			_, err = b.Reload(ctx)
+			require.NoError(t, err)
+			_, err = b.Filter().Clean("what a kerfuffle")
+			assert.ErrorIs(t, err, profanity.ErrProfane)
+			got, err = b.Filter().Clean("zorblax")
+			require.NoError(t, err)
+			assert.Equal(t, "zorblax", got, "renamed words stop matching")
+
+			n, err := st.DeleteModerationWord(ctx, word.ID)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), n)
+			changed, err = b.Reload(ctx)
+			require.NoError(t, err)
+			assert.True(t, changed)
+			got, err = b.Filter().Clean("what a kerfuffle")
+			require.NoError(t, err)
+			assert.Equal(t, "what a ****", got)
+		})
+	}
+}
+
+func TestModerationWordsAPI(t *testing.T) {
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			c := newE2E(t, st, "prod")
+			wordlist, err := moderation.NewWordlist(st, profanity.DefaultWords, profanity.Options{})
+			require.NoError(t, err)
+			c.cfg.Moderation = wordlist
+			admin := bearer(c.staff("admin@example.com", "moderator").Token)
+
+			status, _ := c.do("GET", "/admin/moderation/words", "ApiKey wrong", nil)
+			assert.Equal(t, http.StatusUnauthorized, status)
+
+			status, data := c.do("POST", "/admin/moderation/words", admin, models.ModerationWordRequest{Word: " Zorblax "})
+			require.Equal(t, http.StatusCreated, status, string(data))
+			var word models.ModerationWordResponse
+			c.decode(data, &word)
+			assert.Equal(t, "zorblax", word.Word)
+			assert.Equal(t, "mask", word.Severity)
+
+			user := c.signup("mod@example.com", "pw")
+			token := c.login("mod@example.com", "pw").Token
+			assert.Equal(t, "a **** day", c.chirp(token, "a Zorblax day").Body, "applied without a restart")
+
+			tests := []struct {
+				name   string
+				method string
+				path   string
+				body   any
+				want   int
+			}{
+				{"duplicate", "POST", "/admin/moderation/words", models.ModerationWordRequest{Word: "zorblax"}, http.StatusConflict},
+				{"two words", "POST", "/admin/moderation/words", models.ModerationWordRequest{Word: "zor blax"}, http.StatusBadRequest},
+				{"bad severity", "POST", "/admin/moderation/words", models.ModerationWordRequest{Word: "quux", Severity: "ban"}, http.StatusBadRequest},
+				{"invalid JSON", "POST", "/admin/moderation/words", "{", http.StatusBadRequest},
+				{"update unknown", "PUT", "/admin/moderation/words/" + uuid.NewString(), models.ModerationWordRequest{Word: "quux"}, http.StatusNotFound},
+				{"update bad ID", "PUT", "/admin/moderation/words/nope", models.ModerationWordRequest{Word: "quux"}, http.StatusBadRequest},
+				{"delete unknown", "DELETE", "/admin/moderation/words/" + uuid.NewString(), nil, http.StatusNotFound},
+				{"block", "PUT", "/admin/moderation/words/" + word.ID.String(), models.ModerationWordRequest{Word: "zorblax", Severity: "block"}, http.StatusOK},
+			}
+			for _, tt := range tests {
+				status, data := c.do(tt.method, tt.path, admin, tt.body)
+				assert.Equal(t, tt.want, status, "%s: %s", tt.name, data)
+			}
+
+			status, data = c.do("POST", "/api/chirps", bearer(token), models.ChirpRequest{Body: "a zorblax day", UserID: user.ID})
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Contains(t, string(data), "prohibited language")
+
+			status, data = c.do("GET", "/admin/moderation/words", admin, nil)
+			require.Equal(t, http.StatusOK, status)
+			var words []models.ModerationWordResponse
+			c.decode(data, &words)
+			require.Len(t, words, 1)
+			assert.Equal(t, "block", words[0].Severity)
+
+			status, _ = c.do("DELETE", "/admin/moderation/words/"+word.ID.String(), admin, nil)
+			assert.Equal(t, http.StatusNoContent, status)
+			assert.Equal(t, "a zorblax day", c.chirp(token, "a zorblax day").Body)
+		})
+	}
+}
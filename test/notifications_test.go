@@ -0,0 +1,269 @@
+package test
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/models"
+	"chirpy/internal/notify"
+	"chirpy/internal/store"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications(t *testing.T) {
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			c := newE2E(t, st, "prod")
+			alice := c.signup("alice@example.com", "pw")
+			aliceToken := c.login("alice@example.com", "pw").Token
+			first := c.chirp(aliceToken, "first")
+			second := c.chirp(aliceToken, "second")
+			onChirp := func(id uuid.UUID) uuid.NullUUID { return uuid.NullUUID{UUID: id, Valid: true} }
+
+			// === Grouping: five likers make one notification ===
+			var likers []uuid.UUID
+			for _, email := range []string{"b@example.com", "c@example.com", "d@example.com", "e@example.com", "f@example.com"} {
+				likers = append(likers, c.signup(email, "pw").ID)
+			}
+			for _, liker := range likers {
+				_, changed, err := notify.Record(ctx, st, notify.Event{
+					Type: notify.TypeLike, UserID: alice.ID, ActorID: liker, ChirpID: onChirp(first.ID),
+				})
+				require.NoError(t, err)
+				assert.True(t, changed)
+			}
+			n, changed, err := notify.Record(ctx, st, notify.Event{
+				Type: notify.TypeLike, UserID: alice.ID, ActorID: likers[0], ChirpID: onChirp(first.ID),
+			})
+			require.NoError(t, err)
+			assert.False(t, changed, "same actor again")
+			assert.Equal(t, int32(5), n.ActorCount)
+			assert.Equal(t, likers[4], n.LastActorID)
+
+			_, changed, err = notify.Record(ctx, st, notify.Event{
+				Type: notify.TypeLike, UserID: alice.ID, ActorID: alice.ID, ChirpID: onChirp(first.ID),
+			})
+			require.NoError(t, err)
+			assert.False(t, changed, "liking your own chirp")
+
+			// Other chirps and types are separate groups.
+			for _, evt := range []notify.Event{
+				{Type: notify.TypeReply, UserID: alice.ID, ActorID: likers[1], ChirpID: onChirp(first.ID)},
+				{Type: notify.TypeLike, UserID: alice.ID, ActorID: likers[1], ChirpID: onChirp(second.ID)},
+				{Type: notify.TypeFollow, UserID: alice.ID, ActorID: likers[2]},
+				{Type: notify.TypeFollow, UserID: alice.ID, ActorID: likers[3]},
+			} {
+				_, _, err := notify.Record(ctx, st, evt)
+				require.NoError(t, err)
+			}
+
+			list := func(query url.Values) models.NotificationListResponse {
+				status, data := c.do("GET", "/api/notifications?"+query.Encode(), bearer(aliceToken), nil)
+				require.Equal(t, http.StatusOK, status, string(data))
+				var resp models.NotificationListResponse
+				c.decode(data, &resp)
+				return resp
+			}
+
+			// === Listing and pagination ===
+			all := list(nil)
+			require.Len(t, all.Notifications, 4)
+			assert.Equal(t, int64(4), all.UnreadCount)
+			assert.Empty(t, all.NextCursor)
+			assert.Equal(t, notify.TypeFollow, all.Notifications[0].Type, "newest activity first")
+			assert.Equal(t, int32(2), all.Notifications[0].ActorCount)
+			assert.Nil(t, all.Notifications[0].ChirpID)
+			assert.Equal(t, int32(5), all.Notifications[3].ActorCount)
+			assert.Equal(t, int64(4), c.login("alice@example.com", "pw").UnreadNotifications)
+
+			var paged []models.NotificationResponse
+			query := url.Values{"limit": {"3"}}
+			for {
+				page := list(query)
+				paged = append(paged, page.Notifications...)
+				if page.NextCursor == "" {
+					break
+				}
+				query.Set("cursor", page.NextCursor)
+			}
+			assert.Equal(t, all.Notifications, paged)
+
+			// === Marking read ===
+			mark := func(req models.MarkNotificationsReadRequest) (int, models.MarkNotificationsReadResponse) {
+				status, data := c.do("POST", "/api/notifications/read", bearer(aliceToken), req)
+				var resp models.MarkNotificationsReadResponse
+				if status == http.StatusOK {
+					c.decode(data, &resp)
+				}
+				return status, resp
+			}
+			status, _ := mark(models.MarkNotificationsReadRequest{})
+			assert.Equal(t, http.StatusBadRequest, status, "neither ids nor all")
+
+			likes := all.Notifications[3].ID
+			status, marked := mark(models.MarkNotificationsReadRequest{IDs: []uuid.UUID{likes, likes, uuid.New()}})
+			require.Equal(t, http.StatusOK, status)
+			assert.Equal(t, int64(1), marked.Marked)
+			assert.Equal(t, int64(3), marked.UnreadCount)
+
+			unread := list(url.Values{"unread": {"true"}})
+			assert.Len(t, unread.Notifications, 3)
+			assert.True(t, list(nil).Notifications[3].Read)
+
+			// A like after the group was read starts a new one.
+			n, changed, err = notify.Record(ctx, st, notify.Event{
+				Type: notify.TypeLike, UserID: alice.ID, ActorID: likers[0], ChirpID: onChirp(first.ID),
+			})
+			require.NoError(t, err)
+			assert.True(t, changed)
+			assert.NotEqual(t, likes, n.ID)
+			assert.Equal(t, int32(1), n.ActorCount)
+
+			status, marked = mark(models.MarkNotificationsReadRequest{All: true})
+			require.Equal(t, http.StatusOK, status)
+			assert.Equal(t, int64(4), marked.Marked)
+			assert.Zero(t, marked.UnreadCount)
+
+			// === Blocks: no notifications either way ===
+			blocker := c.signup("blocker@example.com", "pw")
+			_, err = st.CreateBlock(ctx, database.CreateBlockParams{
+				BlockerID: blocker.ID, BlockedID: likers[4], CreatedAt: time.Now().UTC(),
+			})
+			require.NoError(t, err)
+			for _, evt := range []notify.Event{
+				{Type: notify.TypeMention, UserID: blocker.ID, ActorID: likers[4], ChirpID: onChirp(first.ID)},
+				{Type: notify.TypeFollow, UserID: likers[4], ActorID: blocker.ID},
+			} {
+				_, changed, err := notify.Record(ctx, st, evt)
+				require.NoError(t, err)
+				assert.False(t, changed, evt.Type)
+			}
+			_, changed, err = notify.Record(ctx, st, notify.Event{
+				Type: notify.TypeFollow, UserID: blocker.ID, ActorID: likers[3],
+			})
+			require.NoError(t, err)
+			assert.True(t, changed, "unrelated users still notify")
+
+			// === Mentions: "@<email>" in a chirp notifies that user ===
+			mentionerToken := c.login("blocker@example.com", "pw").Token
+			mention := c.chirp(mentionerToken, "hi @alice@example.com, @alice@example.com and @nobody@example.com")
+			mentions := list(url.Values{"unread": {"true"}})
+			require.Len(t, mentions.Notifications, 1, "repeats and unknown users are skipped")
+			assert.Equal(t, notify.TypeMention, mentions.Notifications[0].Type)
+			assert.Equal(t, &mention.ID, mentions.Notifications[0].ChirpID)
+			assert.Equal(t, blocker.ID, mentions.Notifications[0].LastActorID)
+			c.chirp(mentionerToken, "@f@example.com is blocked")
+			assert.Zero(t, c.login("f@example.com", "pw").UnreadNotifications)
+
+			// === Errors ===
+			status, _ = c.do("GET", "/api/notifications", "", nil)
+			assert.Equal(t, http.StatusUnauthorized, status)
+			for _, q := range []string{"limit=0", "limit=101", "unread=maybe", "cursor=nope"} {
+				status, _ = c.do("GET", "/api/notifications?"+q, bearer(aliceToken), nil)
+				assert.Equal(t, http.StatusBadRequest, status, q)
+			}
+		})
+	}
+}
+
+// racingRecorder holds the first racers group lookups until all of them
+// have missed, so every Record call then tries to start the group, as
+// concurrent transactions can.
+type racingRecorder struct {
+	store.Store
+	racers  int32
+	calls   atomic.Int32
+	arrived sync.WaitGroup
+}
+
+func (r *racingRecorder) GetUnreadNotificationGroup(ctx context.Context, arg database.GetUnreadNotificationGroupParams) (database.Notification, error) {
+	n, err := r.Store.GetUnreadNotificationGroup(ctx, arg)
+	if r.calls.Add(1) <= r.racers {
+		r.arrived.Done()
+		r.arrived.Wait()
+	}
+	return n, err
+}
+
+func TestNotify_RecordConcurrently(t *testing.T) {
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			c := newE2E(t, st, "prod")
+			alice := c.signup("alice@example.com", "pw")
+			chirp := c.chirp(c.login("alice@example.com", "pw").Token, "popular")
+			onChirp := uuid.NullUUID{UUID: chirp.ID, Valid: true}
+			var likers []uuid.UUID
+			for i := 0; i < 8; i++ {
+				likers = append(likers, c.signup(fmt.Sprintf("liker%d@example.com", i), "pw").ID)
+			}
+
+			// Every liker finds no group and tries to start one; the
+			// unique index makes all but one join it instead.
+			q := &racingRecorder{Store: st, racers: int32(len(likers))}
+			q.arrived.Add(len(likers))
+			var wg sync.WaitGroup
+			errs := make(chan error, len(likers))
+			for _, liker := range likers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, _, err := notify.Record(ctx, q, notify.Event{
+						Type: notify.TypeLike, UserID: alice.ID, ActorID: liker, ChirpID: onChirp,
+					})
+					errs <- err
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				require.NoError(t, err)
+			}
+
+			unread, err := st.CountUnreadNotifications(ctx, alice.ID)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), unread)
+			n, err := st.GetUnreadNotificationGroup(ctx, database.GetUnreadNotificationGroupParams{
+				UserID: alice.ID, Type: notify.TypeLike, ChirpID: onChirp,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, int32(len(likers)), n.ActorCount)
+		})
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{"no mentions here", nil},
+		{"@alice@example.com hi", []string{"alice@example.com"}},
+		{"thanks @alice@example.com.", []string{"alice@example.com"}},
+		{"(@bob@example.co.uk) and @alice@example.com, @bob@example.co.uk", []string{"bob@example.co.uk", "alice@example.com"}},
+		{"mail alice@example.com", nil},
+		{"foo@@alice@example.com", nil},
+		{"@alice", nil},
+		{strings.Repeat("@a@b.io @c@d.io @e@f.io @g@h.io ", 3), []string{"a@b.io", "c@d.io", "e@f.io", "g@h.io"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, notify.Mentions(tt.body), tt.body)
+	}
+
+	var many []string
+	for i := 0; i < notify.MaxMentions+5; i++ {
+		many = append(many, fmt.Sprintf("@user%d@example.com", i))
+	}
+	assert.Len(t, notify.Mentions(strings.Join(many, " ")), notify.MaxMentions)
+}
@@ -0,0 +1,217 @@
+package test
+
+import (
+	"chirpy/internal/config"
+	"chirpy/internal/database"
+	"chirpy/internal/migrate"
+	"chirpy/internal/outbox"
+	"chirpy/internal/store"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openOutboxDB opens a migrated database with no users or outbox events.
+func openOutboxDB(t *testing.T, driver, url string) *sql.DB {
+	t.Helper()
+	ctx := context.Background()
+	db, err := store.Open(driver, url)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	_, err = migrate.Up(ctx, db, driver)
+	require.NoError(t, err)
+	require.NoError(t, store.NewSQL(db, driver).DeleteAllUsers(ctx))
+	_, err = db.ExecContext(ctx, "DELETE FROM outbox_events")
+	require.NoError(t, err)
+	return db
+}
+
+func TestRelay_SQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := store.Open(config.DriverSQLite, "sqlite://"+filepath.Join(t.TempDir(), "chirpy.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = migrate.Up(ctx, db, config.DriverSQLite)
+	require.NoError(t, err)
+	require.NoError(t, migrate.CheckCurrent(ctx, db, config.DriverSQLite))
+
+	st := store.NewSQL(db, config.DriverSQLite)
+	user, err := st.CreateUser(ctx, userParams("a@example.com"))
+	require.NoError(t, err)
+	err = st.InTx(ctx, func(tx store.Store) error {
+		chirp, err := tx.CreateChirps(ctx, chirpParams(user.ID, "hello"))
+		if err != nil {
+			return err
+		}
+		return outbox.Enqueue(ctx, tx, outbox.EventChirpCreated, chirp.ID, outbox.ChirpPayload{
+			ChirpID: chirp.ID,
+			UserID:  chirp.UserID,
+		})
+	})
+	require.NoError(t, err)
+
+	relay := outbox.NewRelay(db, config.DriverSQLite)
+	var got []outbox.Event
+	relay.Subscribe(outbox.EventChirpCreated, func(ctx context.Context, evt outbox.Event) error {
+		got = append(got, evt)
+		return nil
+	})
+
+	n, err := relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.Len(t, got, 1)
+	assert.Equal(t, outbox.EventChirpCreated, got[0].Type)
+
+	// Published events are not delivered again.
+	n, err = relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestIsForeignKeyViolation_SQLite(t *testing.T) {
+	st := openTestDB(t, config.DriverSQLite, "sqlite://"+filepath.Join(t.TempDir(), "chirpy.db"))
+	_, err := st.CreateChirps(context.Background(), chirpParams(userParams("x").ID, "orphan"))
+	require.Error(t, err)
+	assert.True(t, store.IsForeignKeyViolation(err), err)
+	assert.False(t, store.IsUniqueViolation(err), err)
+}
+
+func TestOutbox_EnqueueCommitsWithChirp(t *testing.T) {
+	ctx := context.Background()
+	db := openOutboxDB(t, config.DriverSQLite, "sqlite://"+filepath.Join(t.TempDir(), "chirpy.db"))
+	st := store.NewSQL(db, config.DriverSQLite)
+	relay := outbox.NewRelay(db, config.DriverSQLite)
+	var got []outbox.Event
+	relay.Subscribe(outbox.AllEvents, func(ctx context.Context, evt outbox.Event) error {
+		got = append(got, evt)
+		return nil
+	})
+
+	// A rolled-back chirp leaves no event behind.
+	user, err := st.CreateUser(ctx, userParams("a@example.com"))
+	require.NoError(t, err)
+	chirp := chirpParams(user.ID, "never")
+	err = st.InTx(ctx, func(tx store.Store) error {
+		if _, err := tx.CreateChirps(ctx, chirp); err != nil {
+			return err
+		}
+		if err := outbox.Enqueue(ctx, tx, outbox.EventChirpCreated, chirp.ID, outbox.ChirpPayload{ChirpID: chirp.ID}); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+	require.EqualError(t, err, "abort")
+	_, err = st.GetChirpByID(ctx, chirp.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	n, err := relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// The handlers enqueue alongside the chirp.
+	c := newE2E(t, st, "prod")
+	c.signup("b@example.com", "pw")
+	token := c.login("b@example.com", "pw").Token
+	created := c.chirp(token, "hello")
+	status, _ := c.do("DELETE", "/api/chirps/"+created.ID.String(), bearer(token), nil)
+	require.Equal(t, http.StatusNoContent, status)
+
+	n, err = relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	require.Len(t, got, 2)
+	for i, want := range []string{outbox.EventChirpCreated, outbox.EventChirpDeleted} {
+		assert.Equal(t, want, got[i].Type)
+		assert.Equal(t, created.ID, got[i].AggregateID)
+	}
+}
+
+func TestRelay_RetriesFailedDeliveries(t *testing.T) {
+	ctx := context.Background()
+	db := openOutboxDB(t, config.DriverSQLite, "sqlite://"+filepath.Join(t.TempDir(), "chirpy.db"))
+	st := store.NewSQL(db, config.DriverSQLite)
+	user, err := st.CreateUser(ctx, userParams("a@example.com"))
+	require.NoError(t, err)
+	require.NoError(t, outbox.Enqueue(ctx, st, outbox.EventChirpCreated, user.ID, outbox.ChirpPayload{}))
+
+	relay := outbox.NewRelay(db, config.DriverSQLite)
+	relay.MaxAttempts = 2
+	calls, fail := 0, true
+	relay.Subscribe(outbox.EventChirpCreated, func(ctx context.Context, evt outbox.Event) error {
+		calls++
+		assert.Equal(t, int32(calls-1), evt.Attempts)
+		if fail {
+			return errors.New("subscriber down")
+		}
+		return nil
+	})
+
+	// At-least-once: a failed delivery stays pending and is retried.
+	n, err := relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	fail = false
+	n, err = relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, err = relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n, "published after the retry succeeded")
+	assert.Equal(t, 2, calls)
+
+	// Events that keep failing are given up on after MaxAttempts.
+	require.NoError(t, outbox.Enqueue(ctx, st, outbox.EventChirpCreated, user.ID, outbox.ChirpPayload{}))
+	calls, fail = 0, true
+	for range 3 {
+		_, err = relay.ProcessBatch(ctx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+// TestRelay_SkipLocked checks that concurrent relays on Postgres never
+// claim the same event.
+func TestRelay_SkipLocked(t *testing.T) {
+	url := os.Getenv("TEST_DB_URL")
+	if url == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+	ctx := context.Background()
+	db := openOutboxDB(t, config.DriverPostgres, url)
+	st := store.NewSQL(db, config.DriverPostgres)
+	user, err := st.CreateUser(ctx, userParams("a@example.com"))
+	require.NoError(t, err)
+	for range 3 {
+		require.NoError(t, outbox.Enqueue(ctx, st, outbox.EventChirpCreated, user.ID, outbox.ChirpPayload{}))
+	}
+
+	// Another relay holds the two oldest events in an open transaction.
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	held, err := database.New(tx).ClaimPendingOutboxEvents(ctx, database.ClaimPendingOutboxEventsParams{Attempts: 10, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, held, 2)
+
+	relay := outbox.NewRelay(db, config.DriverPostgres)
+	relay.SkipLocked = true
+	var got []outbox.Event
+	relay.Subscribe(outbox.AllEvents, func(ctx context.Context, evt outbox.Event) error {
+		got = append(got, evt)
+		return nil
+	})
+	n, err := relay.ProcessBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "only the unlocked event")
+	require.Len(t, got, 1)
+	for _, h := range held {
+		assert.NotEqual(t, h.ID, got[0].ID)
+	}
+}
@@ -0,0 +1,134 @@
+package test
+
+import (
+	"chirpy/internal/api"
+	"chirpy/internal/auth"
+	"chirpy/internal/handlers"
+	"chirpy/internal/profanity"
+	"chirpy/internal/store"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfanityFilter_Mask(t *testing.T) {
+	f := profanity.Default()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain", "this is a kerfuffle", "this is a ****"},
+		{"mixed case", "Kerfuffle SHARBERT Fornax", "**** **** ****"},
+		{"trailing punctuation", "Kerfuffle! sharbert, fornax.", "****! ****, ****."},
+		{"leading punctuation", "!kerfuffle (sharbert)", "!**** (****)"},
+		{"apostrophe", "it's kerfuffle's fault", "it's ****'s fault"},
+		{"accents", "kérfüffle", "****"},
+		{"fullwidth", "ｋｅｒｆｕｆｆｌｅ", "****"},
+		{"cyrillic homoglyphs", "kеrfufflе", "****"},
+		{"greek homoglyphs", "fοrnαx", "****"},
+		{"leetspeak", "k3rfuffl3 $h4rb3rt f0rn@x", "**** **** ****"},
+		{"ambiguous one", "kerfuff1e", "****"},
+		{"stretched", "kerrrfuuuuffle fooornax", "**** ****"},
+		{"longer word", "kerfuffles sharberty", "kerfuffles sharberty"},
+		{"digits suffix", "kerfuffle123", "kerfuffle123"},
+		{"clean text", "just a normal chirp", "just a normal chirp"},
+		{"whitespace kept", "  kerfuffle\t\nok ", "  ****\t\nok "},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.Clean(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProfanityFilter_Options(t *testing.T) {
+	words, err := profanity.LoadWords(strings.NewReader("# banned\nkerfuffle\n\n  Fornax  \n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kerfuffle", "Fornax"}, words)
+
+	strict, err := profanity.New(words, profanity.Options{})
+	require.NoError(t, err)
+	got, err := strict.Clean("k3rfuffle kerrfuffle FORNAX")
+	require.NoError(t, err)
+	assert.Equal(t, "k3rfuffle kerrfuffle ****", got, "leetspeak and repeat folding are opt-in")
+
+	asterisks, err := profanity.New(words, profanity.Options{Strategy: profanity.StrategyAsterisks})
+	require.NoError(t, err)
+	got, err = asterisks.Clean("a kérfuffle!")
+	require.NoError(t, err)
+	assert.Equal(t, "a *********!", got)
+
+	reject, err := profanity.New(words, profanity.Options{Strategy: profanity.StrategyReject})
+	require.NoError(t, err)
+	got, err = reject.Clean("what a kerfuffle")
+	assert.ErrorIs(t, err, profanity.ErrProfane)
+	assert.Equal(t, "what a kerfuffle", got)
+	_, err = reject.Clean("all good")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"kerfuffle", "Fornax"}, reject.Matches("kerfuffle, Fornax and friends"))
+
+	_, err = profanity.New(words, profanity.Options{Strategy: "shout"})
+	assert.Error(t, err)
+}
+
+func TestProfanityFilter_Severity(t *testing.T) {
+	f, err := profanity.NewEntries([]profanity.Entry{
+		{Word: "kerfuffle", Severity: profanity.SeverityMask},
+		{Word: "fornax", Severity: profanity.SeverityBlock},
+		{Word: "sharbert", Severity: profanity.SeverityMask},
+		{Word: "$harbert", Severity: profanity.SeverityBlock},
+	}, profanity.Options{Leetspeak: true})
+	require.NoError(t, err)
+
+	got, err := f.Clean("a kerfuffle")
+	require.NoError(t, err)
+	assert.Equal(t, "a ****", got)
+
+	for _, text := range []string{"kerfuffle and F0RNAX", "sharbert!"} {
+		got, err = f.Clean(text)
+		assert.ErrorIs(t, err, profanity.ErrProfane, text)
+		assert.Equal(t, text, got)
+	}
+
+	_, err = profanity.NewEntries([]profanity.Entry{{Word: "fornax", Severity: "loud"}}, profanity.Options{})
+	assert.Error(t, err)
+
+	assert.True(t, profanity.ValidWord("k3rfuffl3"))
+	for _, bad := range []string{"", "two words", "kerfuffle."} {
+		assert.False(t, profanity.ValidWord(bad), bad)
+	}
+}
+
+func TestHandleCreateChirp_RejectsProfanity(t *testing.T) {
+	filter, err := profanity.New(profanity.DefaultWords, profanity.Options{Strategy: profanity.StrategyReject})
+	require.NoError(t, err)
+	cfg := &api.Config{
+		DB:             store.NewMemory(),
+		JWTSecret:      "secret",
+		ChirpMaxLength: 140,
+		Profanity:      filter,
+	}
+	token, err := auth.MakeJWT(uuid.New(), cfg.JWTSecret, time.Hour)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/api/chirps", strings.NewReader(`{"body":"such a Kerfuffle!"}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handlers.HandleCreateChirp(cfg).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "prohibited language")
+}
@@ -0,0 +1,156 @@
+package test
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/moderation"
+	"chirpy/internal/profanity"
+	"chirpy/internal/pubsub"
+	"chirpy/internal/store"
+	"chirpy/internal/stream"
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replicaPubSubs returns, per backend, the PubSubs of two instances that
+// should hear each other: one shared Memory, and when TEST_DB_URL is set,
+// two Postgres listeners on that database.
+func replicaPubSubs(t *testing.T) map[string][2]pubsub.PubSub {
+	t.Helper()
+	mem := pubsub.NewMemory()
+	backends := map[string][2]pubsub.PubSub{"memory": {mem, mem}}
+
+	url := os.Getenv("TEST_DB_URL")
+	if url == "" {
+		return backends
+	}
+	db, err := sql.Open("postgres", url)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	var pair [2]pubsub.PubSub
+	for i := range pair {
+		pg := pubsub.NewPostgres(db, url)
+		t.Cleanup(func() { pg.Close() })
+		go pg.Run(ctx)
+		pair[i] = pg
+	}
+	backends["postgres"] = pair
+	return backends
+}
+
+// receive waits briefly for a payload on ch.
+func receive(ch <-chan string) (string, bool) {
+	select {
+	case payload := <-ch:
+		return payload, true
+	case <-time.After(2 * time.Second):
+		return "", false
+	}
+}
+
+func TestPubSub(t *testing.T) {
+	for name, ps := range replicaPubSubs(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			topic := "chirpy_test_" + name
+			a, b := make(chan string, 10), make(chan string, 10)
+			unsubA, err := ps[0].Subscribe(topic, func(p []byte) { a <- string(p) })
+			require.NoError(t, err)
+			defer unsubA()
+			unsubB, err := ps[1].Subscribe(topic, func(p []byte) { b <- string(p) })
+			require.NoError(t, err)
+
+			require.NoError(t, ps[0].Publish(ctx, topic, []byte("hello")))
+			got, ok := receive(a)
+			assert.True(t, ok)
+			assert.Equal(t, "hello", got)
+			got, ok = receive(b)
+			assert.True(t, ok, "the other instance hears it")
+			assert.Equal(t, "hello", got)
+
+			unsubB()
+			require.NoError(t, ps[1].Publish(ctx, topic, []byte("again")))
+			got, _ = receive(a)
+			assert.Equal(t, "again", got)
+			assert.Empty(t, a, "each instance gets a message once")
+			assert.Empty(t, b, "unsubscribed")
+		})
+	}
+}
+
+func TestHub_Attach(t *testing.T) {
+	for name, ps := range replicaPubSubs(t) {
+		t.Run(name, func(t *testing.T) {
+			var hubs [2]*stream.Hub
+			var subs [2]*stream.Subscription
+			for i := range hubs {
+				hubs[i] = stream.NewHub()
+				require.NoError(t, hubs[i].Attach(ps[i]))
+				subs[i], _, _ = hubs[i].Subscribe("", nil)
+				t.Cleanup(hubs[i].Close)
+			}
+
+			author := uuid.New()
+			hubs[0].Publish("chirp.created", author, map[string]string{"body": "hi"})
+			var got [2]stream.Event
+			for i, sub := range subs {
+				select {
+				case got[i] = <-sub.Events():
+					assert.Equal(t, uint64(1), got[i].ID, "hub %d", i)
+					assert.Equal(t, author, got[i].AuthorID)
+					assert.JSONEq(t, `{"body":"hi"}`, string(got[i].Data))
+				case <-time.After(2 * time.Second):
+					t.Fatalf("hub %d missed the event", i)
+				}
+			}
+
+			// Each replica numbers events in its own epoch, so resuming on
+			// the other one resets the client instead of misreading the ID.
+			assert.NotEqual(t, got[0].StreamID(), got[1].StreamID())
+			sub, replay, complete := hubs[1].Subscribe(got[0].StreamID(), nil)
+			hubs[1].Unsubscribe(sub)
+			assert.False(t, complete)
+			assert.Empty(t, replay)
+			sub, _, complete = hubs[1].Subscribe(got[1].StreamID(), nil)
+			hubs[1].Unsubscribe(sub)
+			assert.True(t, complete)
+		})
+	}
+}
+
+func TestWordlist_Announce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	st := store.NewMemory()
+	ps := pubsub.NewMemory()
+
+	// Two instances sharing one database; b would not poll for an hour.
+	a, err := moderation.NewWordlist(st, profanity.DefaultWords, profanity.Options{})
+	require.NoError(t, err)
+	b, err := moderation.NewWordlist(st, profanity.DefaultWords, profanity.Options{})
+	require.NoError(t, err)
+	b.ReloadInterval = time.Hour
+	require.NoError(t, a.Attach(ps))
+	require.NoError(t, b.Attach(ps))
+	go b.Run(ctx)
+
+	now := time.Now().UTC()
+	_, err = st.CreateModerationWord(ctx, database.CreateModerationWordParams{
+		ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Word: "zorblax", Severity: "mask",
+	})
+	require.NoError(t, err)
+	require.NoError(t, a.Announce(ctx))
+
+	assert.Eventually(t, func() bool {
+		got, err := b.Filter().Clean("zorblax")
+		return err == nil && got == "****"
+	}, 2*time.Second, 10*time.Millisecond)
+}
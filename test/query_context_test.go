@@ -0,0 +1,64 @@
+package test
+
+import (
+	"chirpy/internal/api"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryContext_RouteTimeouts(t *testing.T) {
+	cfg := &api.Config{
+		QueryTimeout: 2 * time.Second,
+		RouteQueryTimeouts: map[string]time.Duration{
+			"GET /api/chirps": 10 * time.Second,
+		},
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want time.Duration
+	}{
+		{name: "route override", path: "/api/chirps", want: 10 * time.Second},
+		{name: "default timeout", path: "/api/chirps/abc", want: 2 * time.Second},
+	}
+
+	mux := http.NewServeMux()
+	var got time.Duration
+	record := func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := cfg.QueryContext(r)
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		got = time.Until(deadline)
+	}
+	mux.HandleFunc("GET /api/chirps", record)
+	mux.HandleFunc("GET /api/chirps/{chirpID}", record)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.InDelta(t, tt.want.Seconds(), got.Seconds(), 0.5)
+		})
+	}
+}
+
+func TestQueryContext_CancelledWithRequest(t *testing.T) {
+	cfg := &api.Config{QueryTimeout: time.Minute}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	reqCtx, cancelReq := context.WithCancel(req.Context())
+	req = req.WithContext(reqCtx)
+
+	ctx, cancel := cfg.QueryContext(req)
+	defer cancel()
+
+	cancelReq()
+	<-ctx.Done()
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+}
@@ -0,0 +1,175 @@
+package test
+
+import (
+	"chirpy/internal/auth"
+	"chirpy/internal/config"
+	"chirpy/internal/migrate"
+	"chirpy/internal/ratelimit"
+	"chirpy/internal/store"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rateLimitStores returns the memory store, a SQLite-backed store and,
+// when TEST_DB_URL is set, a Postgres-backed one.
+func rateLimitStores(t *testing.T) map[string]ratelimit.Store {
+	t.Helper()
+	open := func(driver, url string) ratelimit.Store {
+		ctx := context.Background()
+		db, err := store.Open(driver, url)
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		_, err = migrate.Up(ctx, db, driver)
+		require.NoError(t, err)
+		_, err = db.ExecContext(ctx, "DELETE FROM rate_limit_buckets")
+		require.NoError(t, err)
+
+		st := ratelimit.NewSQL(db, driver)
+		st.ForUpdate = driver == config.DriverPostgres
+		return st
+	}
+
+	stores := map[string]ratelimit.Store{
+		config.DriverMemory: ratelimit.NewMemory(),
+		config.DriverSQLite: open(config.DriverSQLite, "sqlite://"+filepath.Join(t.TempDir(), "chirpy.db")),
+	}
+	if url := os.Getenv("TEST_DB_URL"); url != "" {
+		stores[config.DriverPostgres] = open(config.DriverPostgres, url)
+	}
+	return stores
+}
+
+func TestRateLimitStore_TokenBucket(t *testing.T) {
+	limit := ratelimit.Limit{Requests: 2, Per: time.Minute, Burst: 3}
+	start := time.Date(2025, 11, 5, 12, 0, 0, 0, time.UTC)
+
+	for name, st := range rateLimitStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			// The burst is spent immediately...
+			for i, wantRemaining := range []int{2, 1, 0} {
+				res, err := st.Take(ctx, "k", limit, start)
+				require.NoError(t, err)
+				assert.True(t, res.Allowed, "request %d", i)
+				assert.Equal(t, 3, res.Limit)
+				assert.Equal(t, wantRemaining, res.Remaining)
+			}
+
+			// ...then a request must wait for a token (30s at 2/min).
+			res, err := st.Take(ctx, "k", limit, start)
+			require.NoError(t, err)
+			assert.False(t, res.Allowed)
+			assert.Equal(t, 30*time.Second, res.RetryAfter)
+			assert.Equal(t, 90*time.Second, res.Reset)
+
+			// Other keys have their own bucket.
+			res, err = st.Take(ctx, "other", limit, start)
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+
+			res, err = st.Take(ctx, "k", limit, start.Add(30*time.Second))
+			require.NoError(t, err)
+			assert.True(t, res.Allowed, "refilled one token")
+			assert.Equal(t, 0, res.Remaining)
+
+			// Sweeping forgets idle buckets, which then start full.
+			require.NoError(t, st.Sweep(ctx, start.Add(time.Hour)))
+			res, err = st.Take(ctx, "k", limit, start.Add(31*time.Second))
+			require.NoError(t, err)
+			assert.Equal(t, 2, res.Remaining)
+		})
+	}
+}
+
+func TestLimiter_Wrap(t *testing.T) {
+	const secret = "rate-limit-secret"
+	limiter := ratelimit.New(ratelimit.NewMemory(), map[string]ratelimit.Limit{
+		"POST /api/chirps": {Requests: 1, Per: time.Minute},
+	}, ratelimit.UserOrIP(secret, true))
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	limited := limiter.Wrap("POST /api/chirps", ok)
+
+	token, err := auth.MakeJWT(uuid.New(), secret, time.Hour)
+	require.NoError(t, err)
+	send := func(h http.Handler, remoteAddr, forwardedFor, authz string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/chirps", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		if authz != "" {
+			req.Header.Set("Authorization", authz)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		authz        string
+		want         int
+	}{
+		{"first request from IP", "10.0.0.1:1000", "", "", http.StatusCreated},
+		{"same IP, other port", "10.0.0.1:2000", "", "", http.StatusTooManyRequests},
+		{"other IP", "10.0.0.2:1000", "", "", http.StatusCreated},
+		{"forwarded client", "10.0.0.1:1000", "198.51.100.1, 203.0.113.7", "", http.StatusCreated},
+		{"same forwarded client, forged first hop", "10.0.0.2:1000", "192.0.2.99, 203.0.113.7", "", http.StatusTooManyRequests},
+		{"user keyed separately from IP", "10.0.0.1:1000", "", "Bearer " + token, http.StatusCreated},
+		{"user limited from any IP", "10.0.0.9:1000", "", "Bearer " + token, http.StatusTooManyRequests},
+		{"invalid token falls back to IP", "10.0.0.3:1000", "", "Bearer nope", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := send(limited, tt.remoteAddr, tt.forwardedFor, tt.authz)
+			assert.Equal(t, tt.want, rec.Code)
+			assert.Equal(t, "1;w=60", rec.Header().Get("RateLimit-Policy"))
+			assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+			assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+			if tt.want == http.StatusTooManyRequests {
+				assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+				assert.Contains(t, rec.Body.String(), "Too Many Requests")
+			} else {
+				assert.Empty(t, rec.Header().Get("Retry-After"))
+			}
+		})
+	}
+
+	t.Run("routes without a rule are not limited", func(t *testing.T) {
+		unlimited := limiter.Wrap("GET /api/chirps", ok)
+		for range 3 {
+			rec := send(unlimited, "10.0.0.1:1000", "", "")
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+		}
+	})
+}
+
+func TestParseRateLimits(t *testing.T) {
+	rules, err := config.ParseRateLimits("POST /api/chirps=30/1m, POST /api/users=5/1h")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]config.RateLimitRule{
+		"POST /api/chirps": {Requests: 30, Per: time.Minute},
+		"POST /api/users":  {Requests: 5, Per: time.Hour},
+	}, rules)
+
+	for _, bad := range []string{"POST /api/chirps", "POST /api/chirps=30", "POST /api/chirps=x/1m", "POST /api/chirps=30/soon"} {
+		_, err := config.ParseRateLimits(bad)
+		assert.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"chirpy/internal/models"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlocksAndMutes(t *testing.T) {
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			c := newE2E(t, st, "prod")
+			alice := c.signup("alice@example.com", "pw")
+			aliceToken := c.login("alice@example.com", "pw").Token
+			bob := c.signup("bob@example.com", "pw")
+			bobToken := c.login("bob@example.com", "pw").Token
+			carol := c.signup("carol@example.com", "pw")
+			carolToken := c.login("carol@example.com", "pw").Token
+
+			fromBob := c.chirp(bobToken, "bob here")
+			c.chirp(carolToken, "carol here")
+			c.chirp(aliceToken, "alice here")
+
+			authors := func(token string) []uuid.UUID {
+				authz := ""
+				if token != "" {
+					authz = bearer(token)
+				}
+				status, data := c.do("GET", "/api/chirps", authz, nil)
+				require.Equal(t, http.StatusOK, status)
+				var list []models.ChirpResponse
+				c.decode(data, &list)
+				ids := make([]uuid.UUID, len(list))
+				for i, ch := range list {
+					ids[i] = ch.UserID
+				}
+				return ids
+			}
+			add := func(token, list string, target uuid.UUID) int {
+				status, _ := c.do("POST", "/api/users/me/"+list, bearer(token), models.RelationshipRequest{UserID: target})
+				return status
+			}
+
+			// === Validation ===
+			assert.Equal(t, http.StatusBadRequest, add(aliceToken, "blocks", alice.ID), "self")
+			assert.Equal(t, http.StatusNotFound, add(aliceToken, "blocks", uuid.New()))
+			assert.Equal(t, http.StatusBadRequest, add(aliceToken, "mutes", uuid.Nil))
+			status, _ := c.do("GET", "/api/users/me/blocks", "", nil)
+			assert.Equal(t, http.StatusUnauthorized, status)
+
+			// === Mute: one-sided ===
+			require.Equal(t, http.StatusCreated, add(aliceToken, "mutes", carol.ID))
+			assert.Equal(t, http.StatusConflict, add(aliceToken, "mutes", carol.ID))
+			assert.NotContains(t, authors(aliceToken), carol.ID)
+			assert.Contains(t, authors(carolToken), alice.ID)
+			assert.Len(t, authors(""), 3, "anonymous lists are unfiltered")
+
+			// === Block: both ways, including by ID ===
+			require.Equal(t, http.StatusCreated, add(aliceToken, "blocks", bob.ID))
+			assert.ElementsMatch(t, []uuid.UUID{alice.ID}, authors(aliceToken))
+			assert.NotContains(t, authors(bobToken), alice.ID)
+			status, _ = c.do("GET", "/api/chirps/"+fromBob.ID.String(), bearer(aliceToken), nil)
+			assert.Equal(t, http.StatusNotFound, status)
+			status, data := c.do("GET", "/api/chirps?author_id="+alice.ID.String(), bearer(bobToken), nil)
+			assert.Equal(t, http.StatusOK, status)
+			assert.JSONEq(t, "[]", string(data))
+
+			status, data = c.do("GET", "/api/users/me/blocks", bearer(aliceToken), nil)
+			require.Equal(t, http.StatusOK, status)
+			var blocks []models.RelationshipResponse
+			c.decode(data, &blocks)
+			require.Len(t, blocks, 1)
+			assert.Equal(t, bob.ID, blocks[0].UserID)
+
+			// === Undo ===
+			status, _ = c.do("DELETE", "/api/users/me/blocks/"+bob.ID.String(), bearer(aliceToken), nil)
+			assert.Equal(t, http.StatusNoContent, status)
+			status, _ = c.do("DELETE", "/api/users/me/blocks/"+bob.ID.String(), bearer(aliceToken), nil)
+			assert.Equal(t, http.StatusNotFound, status)
+			status, _ = c.do("DELETE", "/api/users/me/mutes/"+carol.ID.String(), bearer(aliceToken), nil)
+			assert.Equal(t, http.StatusNoContent, status)
+			assert.Len(t, authors(aliceToken), 3)
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package test
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/models"
+	"chirpy/internal/store"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReports(t *testing.T) {
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			c := newE2E(t, st, "prod")
+			admin := bearer(c.staff("mod@example.com", "moderator").Token)
+
+			c.signup("author@example.com", "pw")
+			author := c.login("author@example.com", "pw")
+			c.signup("reporter@example.com", "pw")
+			reporter := c.login("reporter@example.com", "pw").Token
+			c.signup("other@example.com", "pw")
+			other := c.login("other@example.com", "pw").Token
+
+			hideMe := c.chirp(author.Token, "first")
+			suspendMe := c.chirp(author.Token, "second")
+
+			report := func(token string, chirpID uuid.UUID, reason string) (int, models.ReportResponse) {
+				status, data := c.do("POST", "/api/chirps/"+chirpID.String()+"/reports", bearer(token), models.ReportRequest{Reason: reason})
+				var r models.ReportResponse
+				if status == http.StatusCreated {
+					c.decode(data, &r)
+				}
+				return status, r
+			}
+			resolve := func(reportID uuid.UUID, body any) (int, models.ReportResponse) {
+				status, data := c.do("POST", "/admin/reports/"+reportID.String()+"/resolve", admin, body)
+				var r models.ReportResponse
+				if status == http.StatusOK {
+					c.decode(data, &r)
+				}
+				return status, r
+			}
+			queue := func(status string) []models.ReportResponse {
+				code, data := c.do("GET", "/admin/reports?status="+status, admin, nil)
+				require.Equal(t, http.StatusOK, code, string(data))
+				var out []models.ReportResponse
+				c.decode(data, &out)
+				return out
+			}
+
+			// === Reporting ===
+			status, first := report(reporter, hideMe.ID, "spam")
+			require.Equal(t, http.StatusCreated, status)
+			assert.Equal(t, "open", first.Status)
+
+			status, _ = report(reporter, hideMe.ID, "spam")
+			assert.Equal(t, http.StatusConflict, status, "one report per user and chirp")
+			status, _ = report(other, hideMe.ID, "rude")
+			assert.Equal(t, http.StatusBadRequest, status, "unknown reason")
+			status, _ = report(author.Token, hideMe.ID, "spam")
+			assert.Equal(t, http.StatusBadRequest, status, "own chirp")
+			status, _ = report(reporter, uuid.New(), "spam")
+			assert.Equal(t, http.StatusNotFound, status)
+			status, _ = c.do("POST", "/api/chirps/"+hideMe.ID.String()+"/reports", "", models.ReportRequest{Reason: "spam"})
+			assert.Equal(t, http.StatusUnauthorized, status)
+			status, _ = report(other, hideMe.ID, "harassment")
+			require.Equal(t, http.StatusCreated, status)
+
+			open := queue("open")
+			require.Len(t, open, 2)
+			require.NotNil(t, open[0].Chirp)
+			assert.Equal(t, "first", open[0].Chirp.Body)
+			status, _ = c.do("GET", "/admin/reports", "", nil)
+			assert.Equal(t, http.StatusUnauthorized, status)
+			status, _ = c.do("GET", "/admin/reports", bearer(reporter), nil)
+			assert.Equal(t, http.StatusForbidden, status)
+
+			// === Hide: settles every open report on the chirp ===
+			status, resolved := resolve(first.ID, models.ResolveReportRequest{Action: "hide"})
+			require.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "resolved", resolved.Status)
+			assert.Equal(t, "hide", resolved.Action)
+			assert.NotEmpty(t, resolved.ResolvedAt)
+			assert.Empty(t, queue("open"))
+			assert.Len(t, queue("resolved"), 2)
+
+			status, _ = resolve(first.ID, models.ResolveReportRequest{Action: "hide"})
+			assert.Equal(t, http.StatusConflict, status)
+
+			var list []models.ChirpResponse
+			_, data := c.do("GET", "/api/chirps", "", nil)
+			c.decode(data, &list)
+			require.Len(t, list, 1, "hidden from everyone else")
+			assert.Equal(t, suspendMe.ID, list[0].ID)
+
+			_, data = c.do("GET", "/api/chirps", bearer(author.Token), nil)
+			list = nil
+			c.decode(data, &list)
+			require.Len(t, list, 2, "still visible to the author")
+			assert.True(t, list[0].Hidden)
+			assert.False(t, list[1].Hidden)
+
+			status, _ = c.do("GET", "/api/chirps/"+hideMe.ID.String(), bearer(reporter), nil)
+			assert.Equal(t, http.StatusNotFound, status)
+			status, data = c.do("GET", "/api/chirps/"+hideMe.ID.String(), bearer(author.Token), nil)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Contains(t, string(data), `"hidden":true`)
+
+			// === Suspend: revokes the author's sessions ===
+			status, second := report(reporter, suspendMe.ID, "hate")
+			require.Equal(t, http.StatusCreated, status)
+			status, _ = resolve(second.ID, models.ResolveReportRequest{Action: "suspend", SuspendFor: "soon"})
+			assert.Equal(t, http.StatusBadRequest, status)
+			status, resolved = resolve(second.ID, models.ResolveReportRequest{Action: "suspend", SuspendFor: "72h"})
+			require.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "suspend", resolved.Action)
+			status, _ = c.do("POST", "/api/refresh", bearer(author.RefreshToken), nil)
+			assert.Equal(t, http.StatusUnauthorized, status)
+			status, data = c.do("POST", "/api/login", "", models.LoginRequest{Email: "author@example.com", Password: "pw"})
+			assert.Equal(t, http.StatusForbidden, status)
+			assert.Contains(t, string(data), "suspended until")
+
+			// === Dismiss settles only that report; delete removes the chirp ===
+			status, third := report(other, suspendMe.ID, "spam")
+			require.Equal(t, http.StatusCreated, status)
+			status, resolved = resolve(third.ID, models.ResolveReportRequest{Action: "dismiss"})
+			require.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "dismiss", resolved.Action)
+
+			c.signup("fourth@example.com", "pw")
+			fourth := c.login("fourth@example.com", "pw").Token
+			status, last := report(fourth, suspendMe.ID, "violence")
+			require.Equal(t, http.StatusCreated, status)
+			status, _ = resolve(last.ID, models.ResolveReportRequest{Action: "delete"})
+			require.Equal(t, http.StatusOK, status)
+			status, _ = c.do("GET", "/api/chirps/"+suspendMe.ID.String(), "", nil)
+			assert.Equal(t, http.StatusNotFound, status)
+			assert.Len(t, queue("resolved"), 2, "reports are deleted with their chirp")
+
+			status, _ = resolve(uuid.New(), models.ResolveReportRequest{Action: "hide"})
+			assert.Equal(t, http.StatusNotFound, status)
+			status, _ = resolve(first.ID, models.ResolveReportRequest{Action: "ban"})
+			assert.Equal(t, http.StatusBadRequest, status)
+			status, _ = c.do("GET", "/admin/reports?status=pending", admin, nil)
+			assert.Equal(t, http.StatusBadRequest, status)
+		})
+	}
+}
+
+// staleReads serves reports and chirps as they were when first loaded,
+// as a moderator racing another one sees them before either commits.
+type staleReads struct {
+	store.Store
+	reports map[uuid.UUID]database.Report
+	chirps  map[uuid.UUID]database.Chirp
+}
+
+func (s *staleReads) GetReport(ctx context.Context, id uuid.UUID) (database.Report, error) {
+	if r, ok := s.reports[id]; ok {
+		return r, nil
+	}
+	r, err := s.Store.GetReport(ctx, id)
+	if err == nil {
+		s.reports[id] = r
+	}
+	return r, err
+}
+
+func (s *staleReads) GetChirpByID(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	if c, ok := s.chirps[id]; ok {
+		return c, nil
+	}
+	c, err := s.Store.GetChirpByID(ctx, id)
+	if err == nil {
+		s.chirps[id] = c
+	}
+	return c, err
+}
+
+func TestResolveReport_RaceConflicts(t *testing.T) {
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			c := newE2E(t, st, "prod")
+			c.cfg.DB = &staleReads{
+				Store:   st,
+				reports: make(map[uuid.UUID]database.Report),
+				chirps:  make(map[uuid.UUID]database.Chirp),
+			}
+			admin := bearer(c.staff("mod@example.com", "moderator").Token)
+			c.signup("author@example.com", "pw")
+			author := c.login("author@example.com", "pw").Token
+			c.signup("reporter@example.com", "pw")
+			reporter := bearer(c.login("reporter@example.com", "pw").Token)
+
+			for _, action := range []string{"hide", "suspend", "dismiss", "delete"} {
+				chirp := c.chirp(author, action)
+				status, data := c.do("POST", "/api/chirps/"+chirp.ID.String()+"/reports", reporter, models.ReportRequest{Reason: "spam"})
+				require.Equal(t, http.StatusCreated, status, string(data))
+				var report models.ReportResponse
+				c.decode(data, &report)
+
+				path := "/admin/reports/" + report.ID.String() + "/resolve"
+				body := models.ResolveReportRequest{Action: action}
+				status, _ = c.do("POST", path, admin, body)
+				require.Equal(t, http.StatusOK, status, action)
+				var events int
+				if mem, ok := st.(*store.Memory); ok {
+					events = len(mem.OutboxEvents())
+				}
+
+				// The second moderator still sees the report open.
+				status, _ = c.do("POST", path, admin, body)
+				assert.Equal(t, http.StatusConflict, status, action)
+				if mem, ok := st.(*store.Memory); ok {
+					assert.Len(t, mem.OutboxEvents(), events, "%s: no second event", action)
+				}
+				if action == "suspend" {
+					// Lift the suspension so the author can keep chirping.
+					_, err := st.SuspendUser(context.Background(), database.SuspendUserParams{
+						ID: chirp.UserID, UpdatedAt: time.Now().UTC(),
+					})
+					require.NoError(t, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"chirpy/internal/logger"
+	"chirpy/internal/middleware"
+	"chirpy/internal/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{name: "propagates caller ID", incoming: "abc-123", wantSame: true},
+		{name: "generates when missing", incoming: "", wantSame: false},
+		{name: "replaces ID with spaces", incoming: "bad id", wantSame: false},
+		{name: "replaces overlong ID", incoming: strings.Repeat("x", 200), wantSame: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sawLogger bool
+			handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sawLogger = logger.FromContext(r.Context()) != logger.Logger
+				utils.RespondWithError(w, http.StatusBadRequest, "nope")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set("X-Request-ID", tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			got := rec.Header().Get("X-Request-ID")
+			if tt.wantSame {
+				assert.Equal(t, tt.incoming, got)
+			} else {
+				_, err := uuid.Parse(got)
+				assert.NoError(t, err)
+			}
+			assert.True(t, sawLogger)
+
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.Equal(t, "nope", body["error"])
+			assert.Equal(t, got, body["request_id"])
+		})
+	}
+}
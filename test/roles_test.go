@@ -0,0 +1,122 @@
+package test
+
+import (
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	"chirpy/internal/models"
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRole_AtLeast(t *testing.T) {
+	assert.True(t, auth.RoleAdmin.AtLeast(auth.RoleModerator))
+	assert.True(t, auth.RoleModerator.AtLeast(auth.RoleModerator))
+	assert.False(t, auth.RoleUser.AtLeast(auth.RoleModerator))
+	assert.False(t, auth.Role("root").AtLeast(auth.RoleUser))
+
+	_, err := auth.ParseRole("root")
+	assert.Error(t, err)
+}
+
+func TestUserRolesAPI(t *testing.T) {
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			c := newE2E(t, st, "prod")
+			admin := c.staff("admin@example.com", "admin")
+			mod := c.staff("mod@example.com", "moderator")
+			user := c.signup("user@example.com", "pw")
+			login := c.login("user@example.com", "pw")
+			assert.Equal(t, "user", login.Role)
+			assert.Equal(t, "admin", admin.Role)
+
+			setRole := func(authz string, userID uuid.UUID, role string) int {
+				status, _ := c.do("PUT", "/admin/users/"+userID.String()+"/role", authz, models.UpdateRoleRequest{Role: role})
+				return status
+			}
+			assert.Equal(t, http.StatusForbidden, setRole(bearer(mod.Token), user.ID, "moderator"), "admins only")
+			assert.Equal(t, http.StatusBadRequest, setRole(bearer(admin.Token), user.ID, "root"))
+			assert.Equal(t, http.StatusBadRequest, setRole(bearer(admin.Token), admin.ID, "user"), "own role")
+			assert.Equal(t, http.StatusNotFound, setRole(bearer(admin.Token), uuid.New(), "moderator"))
+
+			status, _ := c.do("GET", "/admin/reports", bearer(login.Token), nil)
+			assert.Equal(t, http.StatusForbidden, status)
+			require.Equal(t, http.StatusOK, setRole(bearer(admin.Token), user.ID, "moderator"))
+			status, _ = c.do("GET", "/admin/reports", bearer(login.Token), nil)
+			assert.Equal(t, http.StatusOK, status, "promotion applies to existing tokens")
+
+			require.Equal(t, http.StatusOK, setRole(bearer(admin.Token), mod.ID, "user"))
+			status, _ = c.do("GET", "/admin/reports", bearer(mod.Token), nil)
+			assert.Equal(t, http.StatusForbidden, status, "demotion applies immediately")
+		})
+	}
+}
+
+func TestSuspendedUser(t *testing.T) {
+	for name, st := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			c := newE2E(t, st, "prod")
+			ctx := context.Background()
+			mod := c.staff("mod@example.com", "moderator")
+
+			suspend := func(userID uuid.UUID, until time.Time) {
+				_, err := st.SuspendUser(ctx, database.SuspendUserParams{
+					ID:             userID,
+					SuspendedUntil: sql.NullTime{Time: until, Valid: true},
+					UpdatedAt:      time.Now().UTC(),
+				})
+				require.NoError(t, err)
+			}
+
+			// A session that survived the suspension, e.g. one restored by hand.
+			suspend(mod.ID, time.Now().UTC().Add(time.Hour))
+			status, _ := c.do("POST", "/api/refresh", bearer(mod.RefreshToken), nil)
+			assert.Equal(t, http.StatusForbidden, status)
+			status, _ = c.do("POST", "/api/login", "", models.LoginRequest{Email: "mod@example.com", Password: "pw"})
+			assert.Equal(t, http.StatusForbidden, status)
+			status, _ = c.do("GET", "/admin/reports", bearer(mod.Token), nil)
+			assert.Equal(t, http.StatusForbidden, status, "staff lose privileged routes too")
+
+			// Access tokens issued before the suspension still work until
+			// they expire, but not for writing.
+			c.signup("user@example.com", "pw")
+			user := c.login("user@example.com", "pw")
+			chirp := c.chirp(user.Token, "before the suspension")
+			suspend(user.ID, time.Now().UTC().Add(time.Hour))
+			writes := []struct {
+				method, path string
+				body         any
+			}{
+				{"POST", "/api/chirps", models.ChirpRequest{Body: "after the suspension"}},
+				{"DELETE", "/api/chirps/" + chirp.ID.String(), nil},
+				{"POST", "/api/chirps/" + chirp.ID.String() + "/reports", models.ReportRequest{Reason: "spam"}},
+				{"PUT", "/api/users", models.UpdateUserRequest{Email: "user@example.com", Password: "pw2"}},
+				{"POST", "/api/users/me/blocks", models.RelationshipRequest{UserID: mod.ID}},
+				{"DELETE", "/api/users/me/blocks/" + mod.ID.String(), nil},
+				{"POST", "/api/users/me/mutes", models.RelationshipRequest{UserID: mod.ID}},
+				{"DELETE", "/api/users/me/mutes/" + mod.ID.String(), nil},
+			}
+			for _, w := range writes {
+				status, body := c.do(w.method, w.path, bearer(user.Token), w.body)
+				assert.Equal(t, http.StatusForbidden, status, "%s %s", w.method, w.path)
+				assert.Contains(t, string(body), "Account suspended until", "%s %s", w.method, w.path)
+			}
+			status, _ = c.do("GET", "/api/chirps/"+chirp.ID.String(), bearer(user.Token), nil)
+			assert.Equal(t, http.StatusOK, status, "reads still work")
+
+			// Suspensions lapse on their own.
+			suspend(mod.ID, time.Now().UTC().Add(-time.Minute))
+			c.login("mod@example.com", "pw")
+			status, _ = c.do("POST", "/api/refresh", bearer(mod.RefreshToken), nil)
+			assert.Equal(t, http.StatusOK, status)
+			suspend(user.ID, time.Now().UTC().Add(-time.Minute))
+			c.chirp(user.Token, "after the suspension")
+		})
+	}
+}
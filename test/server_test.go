@@ -0,0 +1,191 @@
+package test
+
+import (
+	"chirpy/internal/api"
+	"chirpy/internal/health"
+	"chirpy/internal/middleware"
+	"chirpy/internal/server"
+	"chirpy/internal/store"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerNew_Options(t *testing.T) {
+	cfg := &api.Config{
+		DB:       store.NewMemory(),
+		Health:   health.NewChecker(),
+		Platform: "dev",
+		PolkaKey: "key",
+	}
+	header := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Embedded", "yes")
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	tests := []struct {
+		name   string
+		opts   []server.Option
+		method string
+		path   string
+		want   int
+	}{
+		{"default admin", nil, "POST", "/admin/reset", http.StatusUnauthorized},
+		{"without admin", []server.Option{server.WithoutAdmin()}, "POST", "/admin/reset", http.StatusNotFound},
+		{"default webhooks", nil, "POST", "/api/polka/webhooks", http.StatusUnauthorized},
+		{"without webhooks", []server.Option{server.WithoutWebhooks()}, "POST", "/api/polka/webhooks", http.StatusNotFound},
+		{"without static", []server.Option{server.WithoutStatic()}, "GET", "/app/", http.StatusNotFound},
+		{"without metrics", []server.Option{server.WithoutMetrics()}, "GET", "/metrics", http.StatusNotFound},
+		{"static dir", []server.Option{server.WithStaticDir("../database")}, "GET", "/app/schema/schema.go", http.StatusOK},
+		{"prefix", []server.Option{server.WithPrefix("/chirpy/")}, "GET", "/chirpy/livez", http.StatusOK},
+		{"prefix rejects unprefixed", []server.Option{server.WithPrefix("/chirpy")}, "GET", "/livez", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			server.New(cfg, tt.opts...).ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+
+	t.Run("custom middleware", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h := server.New(cfg, server.WithMiddleware(header), server.WithPrefix("/chirpy"))
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/chirpy/livez", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "yes", rec.Header().Get("X-Embedded"))
+		assert.NotEmpty(t, rec.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("standard middleware", func(t *testing.T) {
+		req := httptest.NewRequest("OPTIONS", "/api/chirps", nil)
+		req.Header.Set("Origin", "https://chirpy.app")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		rec := httptest.NewRecorder()
+		h := server.New(cfg, server.WithCORS(middleware.CORSOptions{AllowedOrigins: []string{"https://chirpy.app"}}))
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "https://chirpy.app", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+		assert.NotEmpty(t, rec.Header().Get("X-Request-ID"))
+	})
+}
+
+func TestServe_GracefulShutdown(t *testing.T) {
+	cfg := &api.Config{DB: store.NewMemory(), Health: health.NewChecker(), Platform: "prod"}
+	cfg.Health.Register("server", health.NotShuttingDown(cfg.ShuttingDown.Load))
+	started, release := make(chan struct{}), make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+	mux.Handle("/", server.New(cfg))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	base := "http://" + ln.Addr().String()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(ctx, &http.Server{Handler: mux}, ln, server.ShutdownOptions{
+			ShuttingDown: &cfg.ShuttingDown,
+			DrainDelay:   300 * time.Millisecond,
+			Timeout:      5 * time.Second,
+		})
+	}()
+	// Fresh connections each time, so refusals show up.
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	status := func(path string) (int, error) {
+		resp, err := client.Get(base + path)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+	code, err := status("/readyz")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	slow := make(chan string, 1)
+	go func() {
+		resp, err := client.Get(base + "/slow")
+		if err != nil {
+			slow <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		slow <- string(body)
+	}()
+	<-started
+	cancel()
+
+	// Draining: still serving, but no longer ready.
+	assert.Eventually(t, func() bool {
+		code, err := status("/readyz")
+		return err == nil && code == http.StatusServiceUnavailable
+	}, time.Second, 10*time.Millisecond)
+
+	// Then the listener closes while the slow request is still running.
+	assert.Eventually(t, func() bool {
+		_, err := status("/livez")
+		return err != nil
+	}, 2*time.Second, 10*time.Millisecond)
+	select {
+	case err := <-served:
+		t.Fatalf("Serve returned before the in-flight request finished: %v", err)
+	default:
+	}
+
+	close(release)
+	assert.Equal(t, "done", <-slow)
+	select {
+	case err := <-served:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return")
+	}
+}
+
+func TestServe_ShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /stuck", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(ctx, &http.Server{Handler: mux}, ln, server.ShutdownOptions{Timeout: 100 * time.Millisecond})
+	}()
+	go http.Get("http://" + ln.Addr().String() + "/stuck")
+	<-started
+	cancel()
+
+	select {
+	case err := <-served:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not give up on the stuck request")
+	}
+}
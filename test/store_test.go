@@ -0,0 +1,191 @@
+package test
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/store"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func userParams(email string) database.CreateUserParams {
+	now := time.Now().UTC()
+	return database.CreateUserParams{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Email:          email,
+		HashedPassword: "h",
+	}
+}
+
+func chirpParams(userID uuid.UUID, body string) database.CreateChirpsParams {
+	now := time.Now().UTC()
+	return database.CreateChirpsParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Body:      body,
+		UserID:    userID,
+	}
+}
+
+func TestMemoryStore_Users(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemory()
+
+	u, err := s.CreateUser(ctx, userParams("a@example.com"))
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, u.ID)
+
+	_, err = s.CreateUser(ctx, userParams("a@example.com"))
+	assert.ErrorIs(t, err, store.ErrDuplicateKey)
+
+	got, err := s.GetUserByEmail(ctx, "a@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, got.ID)
+
+	_, err = s.GetUserByEmail(ctx, "missing@example.com")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = s.UpdateUser(ctx, database.UpdateUserParams{ID: uuid.New(), Email: "b@example.com"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	n, err := s.UpgradeToChirpyRed(ctx, u.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	n, err = s.UpgradeToChirpyRed(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.Zero(t, n, "unknown user")
+	got, err = s.GetUserByEmail(ctx, "a@example.com")
+	require.NoError(t, err)
+	assert.True(t, got.IsChirpyRed)
+}
+
+func TestMemoryStore_ChirpsRequireAuthor(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemory()
+
+	_, err := s.CreateChirps(ctx, chirpParams(uuid.New(), "hi"))
+	assert.ErrorIs(t, err, store.ErrForeignKey)
+
+	u, err := s.CreateUser(ctx, userParams("a@example.com"))
+	require.NoError(t, err)
+	c, err := s.CreateChirps(ctx, chirpParams(u.ID, "hi"))
+	require.NoError(t, err)
+
+	byAuthor, err := s.GetChirpsByAuthor(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []database.Chirp{c}, byAuthor)
+
+	// Deleting users cascades like the foreign keys do.
+	require.NoError(t, s.DeleteAllUsers(ctx))
+	_, err = s.GetChirpByID(ctx, c.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestMemoryStore_InTx(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemory()
+	u, err := s.CreateUser(ctx, userParams("a@example.com"))
+	require.NoError(t, err)
+
+	errBoom := errors.New("boom")
+	err = s.InTx(ctx, func(tx store.Store) error {
+		if _, err := tx.CreateChirps(ctx, chirpParams(u.ID, "lost")); err != nil {
+			return err
+		}
+		return errBoom
+	})
+	assert.ErrorIs(t, err, errBoom)
+	chirps, err := s.GetAllChirps(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, chirps, "failed transaction must not leave writes behind")
+
+	err = s.InTx(ctx, func(tx store.Store) error {
+		c, err := tx.CreateChirps(ctx, chirpParams(u.ID, "kept"))
+		if err != nil {
+			return err
+		}
+		return tx.InsertOutboxEvent(ctx, database.InsertOutboxEventParams{
+			ID:          uuid.New(),
+			CreatedAt:   time.Now().UTC(),
+			EventType:   "chirp.created",
+			AggregateID: c.ID,
+			Payload:     []byte(`{}`),
+		})
+	})
+	require.NoError(t, err)
+	chirps, err = s.GetAllChirps(ctx)
+	require.NoError(t, err)
+	assert.Len(t, chirps, 1)
+	assert.Len(t, s.OutboxEvents(), 1)
+
+	// Overwrites, deletes and appends are undone too, also on a panic.
+	writeAll := func(tx store.Store) error {
+		if _, err := tx.UpdateUser(ctx, database.UpdateUserParams{
+			ID: u.ID, Email: "changed@example.com", UpdatedAt: time.Now().UTC(),
+		}); err != nil {
+			return err
+		}
+		if err := tx.DeleteChirp(ctx, chirps[0].ID); err != nil {
+			return err
+		}
+		if _, err := tx.CreateChirps(ctx, chirpParams(u.ID, "lost")); err != nil {
+			return err
+		}
+		return tx.InsertOutboxEvent(ctx, database.InsertOutboxEventParams{
+			ID: uuid.New(), CreatedAt: time.Now().UTC(), EventType: "chirp.created",
+			AggregateID: uuid.New(), Payload: []byte(`{}`),
+		})
+	}
+	err = s.InTx(ctx, func(tx store.Store) error {
+		if err := writeAll(tx); err != nil {
+			return err
+		}
+		return errBoom
+	})
+	assert.ErrorIs(t, err, errBoom)
+	assert.Panics(t, func() {
+		_ = s.InTx(ctx, func(tx store.Store) error {
+			require.NoError(t, writeAll(tx))
+			panic("boom")
+		})
+	})
+	got, err := s.GetUserByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, u, got)
+	after, err := s.GetAllChirps(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, chirps, after)
+	assert.Len(t, s.OutboxEvents(), 1)
+}
+
+func TestMemoryStore_ConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemory()
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.CreateUser(ctx, userParams(fmt.Sprintf("u%d@example.com", i)))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	for i := range 50 {
+		_, err := s.GetUserByEmail(ctx, fmt.Sprintf("u%d@example.com", i))
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,172 @@
+package test
+
+import (
+	"bufio"
+	"chirpy/internal/models"
+	"chirpy/internal/store"
+	"chirpy/internal/stream"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub_ReplayAndBackpressure(t *testing.T) {
+	hub := stream.NewHub()
+	hub.ReplaySize = 3
+	hub.ClientBuffer = 2
+	author, other := uuid.New(), uuid.New()
+
+	for i := 0; i < 4; i++ {
+		hub.Publish("chirp.created", author, map[string]int{"n": i})
+	}
+	sub, _, _ := hub.Subscribe("", nil)
+	hub.Publish("chirp.created", author, map[string]int{"n": 4})
+	epoch := (<-sub.Events()).Epoch
+	hub.Unsubscribe(sub)
+	id := func(n int) string { return fmt.Sprintf("%s-%d", epoch, n) }
+
+	// Events 3-5 are buffered; resuming after 3 replays 4 and 5.
+	sub, replay, complete := hub.Subscribe(id(3), nil)
+	assert.True(t, complete)
+	require.Len(t, replay, 2)
+	assert.Equal(t, uint64(4), replay[0].ID)
+	assert.Equal(t, id(4), replay[0].StreamID())
+	hub.Unsubscribe(sub)
+
+	for _, tt := range []struct{ id, why string }{
+		{id(1), "event 2 has left the buffer"},
+		{id(99), "ID past the last event"},
+		{"0123456789ab-5", "ID from another instance or before a restart"},
+		{"5", "ID without an epoch"},
+	} {
+		_, _, complete = hub.Subscribe(tt.id, nil)
+		assert.False(t, complete, tt.why)
+	}
+
+	// Filters apply to live events; an overflowing client is dropped.
+	sub, _, _ = hub.Subscribe("", func(e stream.Event) bool { return e.AuthorID == author })
+	hub.Publish("chirp.created", other, nil)
+	hub.Publish("chirp.created", author, nil)
+	hub.Publish("chirp.created", author, nil)
+	assert.NoError(t, sub.Err())
+	evt := <-sub.Events()
+	assert.Equal(t, author, evt.AuthorID)
+	hub.Publish("chirp.created", author, nil)
+	hub.Publish("chirp.created", author, nil)
+	<-sub.Done()
+	assert.ErrorIs(t, sub.Err(), stream.ErrSlowConsumer)
+
+	live, _, _ := hub.Subscribe("", nil)
+	hub.Close()
+	<-live.Done()
+	assert.ErrorIs(t, live.Err(), stream.ErrClosed)
+}
+
+// sseEvent is one parsed text/event-stream event.
+type sseEvent struct {
+	id, event, data string
+}
+
+// openStream connects to path and returns a function reading the next
+// event, skipping comments and the retry field.
+func openStream(t *testing.T, c *e2eClient, path string, header http.Header) func() sseEvent {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, "GET", c.srv.URL+path, nil)
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := bufio.NewScanner(resp.Body)
+	return func() sseEvent {
+		t.Helper()
+		var evt sseEvent
+		for lines.Scan() {
+			field, value, _ := strings.Cut(lines.Text(), ": ")
+			switch field {
+			case "id":
+				evt.id = value
+			case "event":
+				evt.event = value
+			case "data":
+				evt.data = value
+			case "":
+				if evt.event != "" {
+					return evt
+				}
+			}
+		}
+		t.Fatalf("stream ended: %v", lines.Err())
+		return evt
+	}
+}
+
+func TestChirpStream(t *testing.T) {
+	c := newE2E(t, store.NewMemory(), "prod")
+	hub := stream.NewHub()
+	hub.Heartbeat = 10 * time.Millisecond
+	c.cfg.Stream = hub
+
+	alice := c.signup("alice@example.com", "pw")
+	aliceToken := c.login("alice@example.com", "pw").Token
+	c.signup("bob@example.com", "pw")
+	bobToken := c.login("bob@example.com", "pw").Token
+
+	all := openStream(t, c, "/api/stream/chirps", nil)
+	onlyAlice := openStream(t, c, "/api/stream/chirps?author_id="+alice.ID.String(), nil)
+
+	c.chirp(bobToken, "from bob")
+	first := c.chirp(aliceToken, "from alice")
+
+	evt := all()
+	assert.Equal(t, "chirp.created", evt.event)
+	assert.Contains(t, evt.data, "from bob")
+	epoch, seq, ok := strings.Cut(evt.id, "-")
+	require.True(t, ok, evt.id)
+	assert.Equal(t, "1", seq)
+	id := func(n int) string { return fmt.Sprintf("%s-%d", epoch, n) }
+	evt = all()
+	assert.Equal(t, id(2), evt.id)
+
+	evt = onlyAlice()
+	assert.Equal(t, id(2), evt.id, "bob's chirp is filtered out")
+	var created models.ChirpResponse
+	require.NoError(t, json.Unmarshal([]byte(evt.data), &created))
+	assert.Equal(t, first.ID, created.ID)
+
+	status, _ := c.do("DELETE", "/api/chirps/"+first.ID.String(), bearer(aliceToken), nil)
+	require.Equal(t, http.StatusNoContent, status)
+	evt = all()
+	assert.Equal(t, "chirp.deleted", evt.event)
+	assert.Contains(t, evt.data, first.ID.String())
+
+	// Resume after event 1: events 2 and 3 are replayed.
+	resumed := openStream(t, c, "/api/stream/chirps", http.Header{"Last-Event-Id": {id(1)}})
+	assert.Equal(t, id(2), resumed().id)
+	assert.Equal(t, id(3), resumed().id)
+
+	// IDs from another replica, a restart or an older server reset the
+	// client.
+	for _, lastID := range []string{id(99), "0123456789ab-1", "1", "nope"} {
+		stale := openStream(t, c, "/api/stream/chirps?last_event_id="+lastID, nil)
+		assert.Equal(t, "stream.reset", stale().event, lastID)
+	}
+
+	status, _ = c.do("GET", "/api/stream/chirps?author_id=nope", "", nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
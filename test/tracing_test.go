@@ -0,0 +1,144 @@
+package test
+
+import (
+	"chirpy/internal/api"
+	"chirpy/internal/auth"
+	"chirpy/internal/config"
+	"chirpy/internal/health"
+	"chirpy/internal/middleware"
+	"chirpy/internal/server"
+	"chirpy/internal/store"
+	"chirpy/internal/tracing"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider that records every span.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { _ = tp.Shutdown(t.Context()) })
+	return recorder
+}
+
+func TestTracingMiddleware_ContinuesW3CTraceContext(t *testing.T) {
+	recorder := recordSpans(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/chirps/{chirpID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/chirps/123", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	middleware.Tracing(middleware.Route(mux)).ServeHTTP(rec, req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /api/chirps/{chirpID}", spans[0].Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	assert.Contains(t, rec.Header().Get("traceparent"), "4bf92f3577b34da6a3ce929d0e0e4736")
+}
+
+func TestServerNew_NamesSpansAfterRoute(t *testing.T) {
+	recorder := recordSpans(t)
+	cfg := &api.Config{
+		DB:        store.NewMemory(),
+		Health:    health.NewChecker(),
+		JWTSecret: "secret",
+	}
+
+	rec := httptest.NewRecorder()
+	server.New(cfg).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/chirps/"+uuid.NewString(), nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /api/chirps/{chirpID}", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("http.route", "GET /api/chirps/{chirpID}"))
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("http.response.status_code", http.StatusNotFound))
+}
+
+func TestWrapDBTX_SpansCoverReadingRows(t *testing.T) {
+	recorder := recordSpans(t)
+	db, err := store.Open(config.DriverSQLite, "sqlite://"+filepath.Join(t.TempDir(), "chirpy.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx, parent := tracing.Tracer().Start(context.Background(), "request")
+	q := tracing.WrapDBTX(db, config.DriverSQLite)
+	rows, err := q.QueryContext(ctx, "-- name: Numbers :many\nSELECT 1 UNION ALL SELECT 2")
+	require.NoError(t, err)
+	assert.Empty(t, recorder.Ended(), "span open while rows are read")
+	var n int
+	for rows.Next() {
+		require.NoError(t, rows.Scan(&n))
+	}
+	require.NoError(t, rows.Close())
+	var one int
+	require.NoError(t, q.QueryRowContext(ctx, "-- name: One :one\nSELECT 1").Scan(&one))
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	for i, name := range []string{"db.Numbers", "db.One"} {
+		assert.Equal(t, name, spans[i].Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), spans[i].Parent().SpanID())
+		assert.Contains(t, spans[i].Attributes(), attribute.String("db.system", "sqlite"))
+	}
+}
+
+func TestStoreQueries_AreTraced(t *testing.T) {
+	recorder := recordSpans(t)
+	st := openTestDB(t, config.DriverSQLite, "sqlite://"+filepath.Join(t.TempDir(), "chirpy.db"))
+	recorder.Reset()
+
+	_, err := st.GetAllChirps(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, st.InTx(context.Background(), func(tx store.Store) error {
+		_, err := tx.GetChirpsByAuthor(context.Background(), userParams("a").ID)
+		return err
+	}))
+
+	var names []string
+	for _, s := range recorder.Ended() {
+		names = append(names, s.Name())
+	}
+	assert.Equal(t, []string{"db.GetAllChirps", "db.GetChirpsByAuthor"}, names)
+}
+
+func TestPasswordHashing_IsTraced(t *testing.T) {
+	recorder := recordSpans(t)
+	ctx, parent := tracing.Tracer().Start(context.Background(), "request")
+	hash, err := auth.HashPassword(ctx, "pw")
+	require.NoError(t, err)
+	ok, err := auth.CheckPasswordHash(ctx, "pw", hash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "auth.HashPassword", spans[0].Name())
+	assert.Equal(t, "auth.CheckPasswordHash", spans[1].Name())
+	for _, s := range spans[:2] {
+		assert.Equal(t, parent.SpanContext().SpanID(), s.Parent().SpanID())
+	}
+}
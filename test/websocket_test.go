@@ -0,0 +1,175 @@
+package test
+
+import (
+	"chirpy/internal/models"
+	"chirpy/internal/store"
+	"chirpy/internal/stream"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dialSocket opens /api/ws with header and returns the connection, or nil
+// and the HTTP response when the upgrade is refused.
+func dialSocket(t *testing.T, c *e2eClient, query string, header http.Header) (*websocket.Conn, *http.Response) {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(c.srv.URL, "http") + "/api/ws" + query
+	conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		require.NotNil(t, resp, "dial: %v", err)
+		return nil, resp
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, resp
+}
+
+// next reads one frame, failing the test if none arrives promptly.
+func next(t *testing.T, conn *websocket.Conn) models.SocketMessage {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var msg models.SocketMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+func send(t *testing.T, conn *websocket.Conn, reqType, channel string) models.SocketMessage {
+	t.Helper()
+	require.NoError(t, conn.WriteJSON(models.SocketRequest{Type: reqType, Channel: channel}))
+	return next(t, conn)
+}
+
+func TestChirpSocket(t *testing.T) {
+	c := newE2E(t, store.NewMemory(), "prod")
+	hub := stream.NewHub()
+	c.cfg.Stream = hub
+
+	alice := c.signup("alice@example.com", "pw")
+	aliceToken := c.login("alice@example.com", "pw").Token
+	bob := c.signup("bob@example.com", "pw")
+	bobToken := c.login("bob@example.com", "pw").Token
+
+	t.Run("authentication", func(t *testing.T) {
+		c.cfg.AllowedOrigins = []string{"https://app.example.com"}
+		defer func() { c.cfg.AllowedOrigins = nil }()
+		withToken := func(kv ...string) http.Header {
+			h := http.Header{"Sec-WebSocket-Protocol": {"access_token, " + bobToken}}
+			for i := 0; i < len(kv); i += 2 {
+				h.Set(kv[i], kv[i+1])
+			}
+			return h
+		}
+		tests := []struct {
+			name   string
+			query  string
+			header http.Header
+			status int
+		}{
+			{"no token", "", nil, http.StatusUnauthorized},
+			{"bad token", "", http.Header{"Sec-WebSocket-Protocol": {"access_token, nope"}}, http.StatusUnauthorized},
+			{"query no longer accepted", "?access_token=" + bobToken, nil, http.StatusUnauthorized},
+			{"header", "", http.Header{"Authorization": {bearer(bobToken)}}, http.StatusSwitchingProtocols},
+			{"subprotocol", "", withToken(), http.StatusSwitchingProtocols},
+			{"same origin", "", withToken("Origin", c.srv.URL), http.StatusSwitchingProtocols},
+			{"allowed origin", "", withToken("Origin", "https://app.example.com"), http.StatusSwitchingProtocols},
+			{"other origin", "", withToken("Origin", "https://evil.example.com"), http.StatusForbidden},
+			{"other origin with header", "", http.Header{
+				"Authorization": {bearer(bobToken)}, "Origin": {"https://evil.example.com"},
+			}, http.StatusForbidden},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, resp := dialSocket(t, c, tt.query, tt.header)
+				assert.Equal(t, tt.status, resp.StatusCode)
+				if tt.header.Get("Sec-WebSocket-Protocol") != "" && tt.status == http.StatusSwitchingProtocols {
+					assert.Equal(t, "access_token", resp.Header.Get("Sec-WebSocket-Protocol"))
+				}
+			})
+		}
+	})
+
+	conn, _ := dialSocket(t, c, "", http.Header{"Authorization": {bearer(bobToken)}})
+
+	t.Run("requests", func(t *testing.T) {
+		tests := []struct {
+			reqType, channel string
+			want             models.SocketMessage
+		}{
+			{"ping", "", models.SocketMessage{Type: "pong"}},
+			{"subscribe", "chirps", models.SocketMessage{Type: "subscribed", Channel: "chirps"}},
+			{"subscribe", "chirps:" + alice.ID.String(), models.SocketMessage{Type: "subscribed", Channel: "chirps:" + alice.ID.String()}},
+			{"subscribe", "notifications", models.SocketMessage{Type: "subscribed", Channel: "notifications"}},
+			{"subscribe", "chirps:nope", models.SocketMessage{Type: "error", Channel: "chirps:nope", Error: "Unknown channel"}},
+			{"shout", "", models.SocketMessage{Type: "error", Error: "Unknown request type"}},
+		}
+		for _, tt := range tests {
+			assert.Equal(t, tt.want, send(t, conn, tt.reqType, tt.channel), tt.reqType+" "+tt.channel)
+		}
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("{")))
+		assert.Equal(t, "error", next(t, conn).Type)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		// Alice's chirp matches two channels but arrives once, on hers.
+		created := c.chirp(aliceToken, "from alice")
+		msg := next(t, conn)
+		assert.Equal(t, "event", msg.Type)
+		assert.Equal(t, "chirps:"+alice.ID.String(), msg.Channel)
+		assert.Equal(t, "chirp.created", msg.Event)
+		var chirp models.ChirpResponse
+		require.NoError(t, json.Unmarshal(msg.Data, &chirp))
+		assert.Equal(t, created.ID, chirp.ID)
+
+		// Only bob's notifications reach bob.
+		hub.PublishTo(alice.ID, "test.notice", bob.ID, nil)
+		hub.PublishTo(bob.ID, "test.notice", alice.ID, map[string]string{"hello": "bob"})
+		msg = next(t, conn)
+		assert.Equal(t, "notifications", msg.Channel)
+		assert.JSONEq(t, `{"hello":"bob"}`, string(msg.Data))
+
+		// Mentioning bob pushes his notification once the chirp commits.
+		mention := c.chirp(aliceToken, "hi @bob@example.com")
+		assert.Equal(t, "chirps:"+alice.ID.String(), next(t, conn).Channel)
+		msg = next(t, conn)
+		assert.Equal(t, "notifications", msg.Channel)
+		assert.Equal(t, "notification", msg.Event)
+		var n models.NotificationResponse
+		require.NoError(t, json.Unmarshal(msg.Data, &n))
+		assert.Equal(t, "mention", n.Type)
+		assert.Equal(t, &mention.ID, n.ChirpID)
+		assert.Equal(t, alice.ID, n.LastActorID)
+
+		assert.Equal(t, "unsubscribed", send(t, conn, "unsubscribe", "chirps:"+alice.ID.String()).Type)
+		c.chirp(bobToken, "from bob")
+		msg = next(t, conn)
+		assert.Equal(t, "chirps", msg.Channel)
+		assert.Contains(t, string(msg.Data), "from bob")
+	})
+
+	t.Run("blocked authors are left out", func(t *testing.T) {
+		status, _ := c.do("POST", "/api/users/me/blocks", bearer(bobToken), models.RelationshipRequest{UserID: alice.ID})
+		require.Equal(t, http.StatusCreated, status)
+		blocked, _ := dialSocket(t, c, "", http.Header{"Authorization": {bearer(bobToken)}})
+		assert.Equal(t, "subscribed", send(t, blocked, "subscribe", "chirps").Type)
+
+		c.chirp(aliceToken, "hidden from bob")
+		c.chirp(bobToken, "visible")
+		assert.Contains(t, string(next(t, blocked).Data), "visible")
+	})
+
+	t.Run("shutdown closes sockets", func(t *testing.T) {
+		hub.Close()
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "got %v", err)
+				break
+			}
+		}
+	})
+}